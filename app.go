@@ -4,7 +4,11 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"jbextractor/jitterbit/export"
+	"jbextractor/jitterbit/javascript"
+	"jbextractor/jitterbit/operation"
 	jbproj "jbextractor/jitterbit/project"
+	jbscript "jbextractor/jitterbit/script"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -12,6 +16,7 @@ import (
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/exp/slices"
 )
 
 // App struct
@@ -134,7 +139,9 @@ func (a *App) GetEnvs(project string) []string {
 }
 
 // Extract performs convertions from Jitterbit Studio format to a more readable project structure.
-func (a *App) Extract(projectPath string, env string, output string) bool {
+// Jitterbit Script files are pretty-printed if format is set, raw operation XML files are copied if rawXml is set
+// and their activities are annotated with the resolved entities if annotateXml is set.
+func (a *App) Extract(projectPath string, env string, output string, format bool, rawXml bool, annotateXml bool) bool {
 	targetPath, err := a.copyMetadata(projectPath, env, output)
 	if err != nil {
 		a.logError(err)
@@ -156,7 +163,15 @@ func (a *App) Extract(projectPath string, env string, output string) bool {
 		return false
 	}
 
-	err = ops.CreateOperations(envPath, targetPath, a.pathSep)
+	if rawXml {
+		err = ops.CreateOperations(envPath, targetPath, a.pathSep)
+		if err != nil {
+			a.logError(err)
+			return false
+		}
+	}
+
+	err = a.createOperationDocs(project, ops, targetPath, rawXml && annotateXml)
 	if err != nil {
 		a.logError(err)
 		return false
@@ -189,7 +204,13 @@ func (a *App) Extract(projectPath string, env string, output string) bool {
 		return false
 	}
 
-	err = a.resolveScripts(project, targetPath)
+	err = a.resolveScripts(project, targetPath, format)
+	if err != nil {
+		a.logError(err)
+		return false
+	}
+
+	err = a.exportEntities(project, targetPath)
 	if err != nil {
 		a.logError(err)
 		return false
@@ -198,6 +219,76 @@ func (a *App) Extract(projectPath string, env string, output string) bool {
 	return true
 }
 
+// exportEntities writes the entity types without a dedicated extraction.
+func (a *App) exportEntities(project *jbproj.Project, targetPath string) error {
+	exporter := export.NewExporter(project, targetPath, a.pathSep)
+	exports := []func() error{
+		exporter.XsltTransforms,
+		exporter.Documents,
+		exporter.WebServiceCalls,
+		exporter.EmailMessages,
+		exporter.Salesforce,
+		exporter.Connectors,
+		exporter.Jms,
+		exporter.ApiEntities,
+		// after all exports with endpoints
+		exporter.Endpoints,
+	}
+	for _, run := range exports {
+		if err := run(); err != nil {
+			return err
+		}
+	}
+	for _, warning := range exporter.Warnings {
+		a.logWarning(warning)
+	}
+	return nil
+}
+
+// createOperationDocs writes a Markdown runbook and a readable YAML definition of each operation,
+// the copied operation XML is annotated if annotate is set.
+func (a *App) createOperationDocs(project *jbproj.Project, ops *jbproj.EntityType, targetPath string, annotate bool) error {
+	describer, err := operation.NewDescriber(project, a.pathSep)
+	if err != nil {
+		return err
+	}
+	entities, err := project.ParseEntities(jbproj.OPERATION, a.pathSep)
+	if err != nil {
+		return err
+	}
+
+	outPath := fmt.Sprintf("%s%sOperation", targetPath, a.pathSep)
+	for _, op := range entities {
+		_, opDir := ops.FindEntity(op.Header.Id, outPath)
+		if opDir == "" {
+			return fmt.Errorf("[CreateOperationDocs] Corrupted project.xml - operation %s was not found", op.Header.Id)
+		}
+		desc := describer.Describe(op)
+		outFilePath := fmt.Sprintf("%s%s%s", opDir, a.pathSep, jbproj.SanitizeFileName(op.Header.Name))
+		if err := os.WriteFile(outFilePath+".md", []byte(describer.Runbook(desc)), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(outFilePath+".yaml", []byte(describer.YAML(desc)), os.ModePerm); err != nil {
+			return err
+		}
+
+		if annotate {
+			data, err := os.ReadFile(outFilePath + ".xml")
+			if err != nil {
+				return err
+			}
+			data, err = describer.Annotate(desc, data)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(outFilePath+".xml", data, os.ModePerm); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Copies over the project and environment metadata files.
 func (a *App) copyMetadata(projectPath string, env string, output string) (string, error) {
 	// get project name
@@ -284,49 +375,20 @@ func getDate() string {
 	return replacer.Replace(date)
 }
 
-func (a *App) resolveScripts(project *jbproj.Project, rootPath string) error {
-	scripts := project.GetEntityType(jbproj.SCRIPT)
-	ops := project.GetEntityType(jbproj.OPERATION)
-
+func (a *App) resolveScripts(project *jbproj.Project, rootPath string, format bool) error {
 	return filepath.WalkDir(rootPath,
 		func(path string, d os.DirEntry, err error) error {
-			if !d.IsDir() {
+			if !d.IsDir() && strings.HasSuffix(path, ".jb") {
 				data, err := os.ReadFile(path)
 				if err != nil {
 					return err
 				}
 
 				script := string(data)
-				// RunScript
-				regex := regexp.MustCompile(`RunScript\(\"sc\.([0-9a-f]{8}\-[0-9a-f]{4}\-[0-9a-f]{4}\-[0-9a-f]{4}\-[0-9a-f]{12})\".*`)
-				matches := regex.FindAllStringSubmatch(script, -1)
-				for _, m := range matches {
-					ent, dir := scripts.FindEntity(m[1], fmt.Sprintf("%s%sData%s%s", project.EnvPath, a.pathSep, a.pathSep, scripts.Type))
-					if ent == nil || dir == "" {
-						a.logWarning(fmt.Sprintf("[ResolveScripts] Script %s could not be found", m[1]))
-						continue
-					}
-					cbPath := makeCallablePath(ent, dir, rootPath, scripts.Type, a.pathSep)
-					replacement := strings.Replace(m[0], fmt.Sprintf("sc.%s", m[1]), cbPath, 1)
-					script = strings.Replace(script, m[0], replacement, 1)
-				}
-
-				// RunOperation
-				regex = regexp.MustCompile(`RunOperation\(\"op\.([0-9a-f]{8}\-[0-9a-f]{4}\-[0-9a-f]{4}\-[0-9a-f]{4}\-[0-9a-f]{12})\".*`)
-				matches = regex.FindAllStringSubmatch(script, -1)
-				for _, m := range matches {
-					ent, dir := ops.FindEntity(m[1], fmt.Sprintf("%s%sData%s%s", project.EnvPath, a.pathSep, a.pathSep, ops.Type))
-					if ent == nil || dir == "" {
-						a.logWarning(fmt.Sprintf("[ResolveScripts] Operation %s could not be found", m[1]))
-						continue
-					}
-					cbPath := makeCallablePath(ent, dir, rootPath, ops.Type, a.pathSep)
-					replacement := strings.Replace(m[0], fmt.Sprintf("op.%s", m[1]), cbPath, 1)
-					script = strings.Replace(script, m[0], replacement, 1)
-				}
+				name := strings.TrimSuffix(d.Name(), ".jb")
 
 				// JavaScript tags
-				regex = regexp.MustCompile(`^<javascript>\n(.|[\r|\n])*\n</javascript>\z`)
+				regex := regexp.MustCompile(`^<javascript>\n(.|[\r|\n])*\n</javascript>\z`)
 				jsMatch := regex.FindStringSubmatch(script)
 
 				if jsMatch != nil {
@@ -337,6 +399,12 @@ func (a *App) resolveScripts(project *jbproj.Project, rootPath string) error {
 					jsMatch[0] = strings.TrimPrefix(jsMatch[0], "<javascript>\n")
 					script = strings.TrimSuffix(jsMatch[0], "\n</javascript>")
 					path = fmt.Sprintf("%s%s", strings.TrimSuffix(path, ".jb"), ".js")
+					script = a.resolveJavaScriptReferences(project, name, script)
+				} else {
+					script = a.resolveReferences(project, name, script)
+					if format {
+						script = a.formatScript(name, script)
+					}
 				}
 
 				file, err := os.Create(path)
@@ -361,17 +429,99 @@ func (a *App) resolveScripts(project *jbproj.Project, rootPath string) error {
 	)
 }
 
-func makeCallablePath(ent *jbproj.Entity, dir string, rootPath string, typeName string, sep string) string {
-	basePath := fmt.Sprintf("%s%s%s", rootPath, sep, typeName)
-	path := fmt.Sprintf("%s%s%s", dir, sep, ent.Name)
-	// diff the paths
-	path = strings.Replace(path, basePath, "", 1)
-	// truncate the first separator
-	path = strings.Replace(path, sep, "", 1)
-	// normalize separators
-	if sep == "\\" {
-		path = strings.Replace(path, sep, "/", -1)
+// resolveReferences substitutes entity IDs passed to built-in functions with <TAG> paths.
+func (a *App) resolveReferences(project *jbproj.Project, name string, script string) string {
+	parsed, errs := jbscript.Parse(script)
+	for _, err := range errs {
+		a.logWarning(fmt.Sprintf("[ResolveScripts] Syntax error in %s at %s", name, err.Error()))
+	}
+
+	edits := []jbscript.Edit{}
+	for _, ref := range jbscript.FindReferences(parsed) {
+		if ref.Dynamic {
+			a.logWarning(fmt.Sprintf("[ResolveScripts] Dynamic %s reference in %s at %s could not be resolved", ref.Call.Name.Name, name, ref.Pos()))
+			continue
+		}
+		// already resolved
+		if ref.Tag != "" {
+			continue
+		}
+
+		et, ent, folders := project.FindEntity(ref.Id)
+		if ent == nil {
+			a.logWarning(fmt.Sprintf("[ResolveScripts] Entity %s referenced in %s at %s could not be found", ref.Id, name, ref.Pos()))
+			continue
+		}
+		if !slices.Contains(ref.Types, et.Name) {
+			a.logWarning(fmt.Sprintf("[ResolveScripts] %s in %s at %s references the %s %s, expected a %s", ref.Call.Name.Name, name, ref.Pos(), et.Name, ent.Name, strings.Join(ref.Types, " or ")))
+			continue
+		}
+		edits = append(edits, jbscript.Edit{
+			Pos:  ref.Literal.Pos(),
+			End:  ref.Literal.End(),
+			Text: jbscript.Quote(makeTagPath(ent, folders, et.Type), ref.Literal),
+		})
+	}
+
+	return jbscript.ApplyEdits(script, edits)
+}
+
+// resolveJavaScriptReferences checks the ES5 syntax of JavaScript code and substitutes entity IDs
+// passed to Jitterbit functions with <TAG> paths.
+func (a *App) resolveJavaScriptReferences(project *jbproj.Project, name string, script string) string {
+	prog, errs := javascript.Parse(script)
+	for _, err := range errs {
+		a.logWarning(fmt.Sprintf("[ResolveScripts] Syntax error in %s at %s", name, err.Error()))
+	}
+	for _, feature := range prog.Features {
+		a.logWarning(fmt.Sprintf("[ResolveScripts] Unsupported JavaScript feature in %s at %s: %s", name, feature.Pos, feature.Name))
+	}
+
+	edits := []jbscript.Edit{}
+	for _, ref := range javascript.FindReferences(prog) {
+		if ref.Dynamic {
+			a.logWarning(fmt.Sprintf("[ResolveScripts] Dynamic %s reference in %s at %s could not be resolved", ref.Call.Callee, name, ref.Pos()))
+			continue
+		}
+		// already resolved
+		if ref.Tag != "" {
+			continue
+		}
+
+		et, ent, folders := project.FindEntity(ref.Id)
+		if ent == nil {
+			a.logWarning(fmt.Sprintf("[ResolveScripts] Entity %s referenced in %s at %s could not be found", ref.Id, name, ref.Pos()))
+			continue
+		}
+		if !slices.Contains(ref.Types, et.Name) {
+			a.logWarning(fmt.Sprintf("[ResolveScripts] %s in %s at %s references the %s %s, expected a %s", ref.Call.Callee, name, ref.Pos(), et.Name, ent.Name, strings.Join(ref.Types, " or ")))
+			continue
+		}
+		edits = append(edits, jbscript.Edit{
+			Pos:  ref.Literal.Pos,
+			End:  ref.Literal.End,
+			Text: javascript.Quote(makeTagPath(ent, folders, et.Type), ref.Literal),
+		})
+	}
+
+	return jbscript.ApplyEdits(script, edits)
+}
+
+// formatScript pretty-prints Jitterbit Script, the original is kept if it cannot be formatted.
+func (a *App) formatScript(name string, script string) string {
+	opts := jbscript.DefaultFormatOptions()
+	opts.LineEnding = a.eol
+	formatted, err := jbscript.Format(script, opts)
+	if err != nil {
+		a.logWarning(fmt.Sprintf("[FormatScript] Script %s was not formatted: %s", name, err.Error()))
+		return script
 	}
+	return formatted
+}
 
-	return fmt.Sprintf("<TAG>%ss/%s</TAG>", typeName, path)
+// makeTagPath returns a <TAG> reference to the entity, e.g. <TAG>Scripts/Folder/Name</TAG>.
+func makeTagPath(ent *jbproj.Entity, folders []string, typeName string) string {
+	names := append([]string{}, folders...)
+	names = append(names, ent.Name)
+	return fmt.Sprintf("<TAG>%ss/%s</TAG>", typeName, strings.Join(names, "/"))
 }
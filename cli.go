@@ -0,0 +1,551 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/anonymize"
+	"jbextractor/jitterbit/catalog"
+	"jbextractor/jitterbit/docs"
+	"jbextractor/jitterbit/interp"
+	"jbextractor/jitterbit/lint"
+	"jbextractor/jitterbit/lsp"
+	"jbextractor/jitterbit/operation"
+	jbproj "jbextractor/jitterbit/project"
+	jbscript "jbextractor/jitterbit/script"
+	"jbextractor/jitterbit/secrets"
+	"jbextractor/jitterbit/transpile"
+	"jbextractor/jitterbit/xref"
+)
+
+// Headless command handler, returns the process exit code.
+type command struct {
+	usage string
+	run   func(app *App, args []string) int
+}
+
+// Commands available from the command line, the GUI starts when none is given.
+var commands = map[string]command{
+	"lint":       {lintUsage, runLint},
+	"fmt":        {fmtUsage, runFmt},
+	"lsp":        {lspUsage, runLsp},
+	"builtins":   {builtinsUsage, runBuiltins},
+	"globals":    {globalsUsage, runGlobals},
+	"test":       {testUsage, runTest},
+	"migrate":    {migrateUsage, runMigrate},
+	"secrets":    {secretsUsage, runSecrets},
+	"anonymize":  {anonymizeUsage, runAnonymize},
+	"docs":       {docsUsage, runDocs},
+	"unannotate": {unannotateUsage, runUnannotate},
+}
+
+// runCommand executes a command-line subcommand.
+func runCommand(app *App, args []string) int {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		printUsage()
+		return 2
+	}
+	return cmd.run(app, args[1:])
+}
+
+// printUsage lists the available commands.
+func printUsage() {
+	names := []string{}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", os.Args[0], commands[name].usage)
+	}
+}
+
+const lintUsage = "lint [-config rules.json] <extraction dir>"
+
+// runLint checks the Jitterbit scripts of an extracted project.
+func runLint(app *App, args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	configPath := flags.String("config", "", "JSON file with rule severities, e.g. {\"rules\": {\"unused-variable\": \"off\"}}")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], lintUsage)
+		return 2
+	}
+
+	config := lint.DefaultConfig()
+	if *configPath != "" {
+		var err error
+		config, err = lint.LoadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	diags, err := lint.LintDir(flags.Arg(0), app.pathSep, config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	failed := false
+	for _, diag := range diags {
+		fmt.Println(diag)
+		if diag.Severity == lint.ERROR {
+			failed = true
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+const fmtUsage = "fmt [-w] [-crlf] [-indent n] <extraction dir or .jb file>"
+
+// runFmt pretty-prints Jitterbit Script files. Without -w the files that need formatting are listed.
+func runFmt(app *App, args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "overwrite the files with the formatted scripts")
+	crlf := flags.Bool("crlf", false, "use CRLF line endings instead of LF")
+	indent := flags.Int("indent", 2, "number of spaces per indentation level")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], fmtUsage)
+		return 2
+	}
+
+	opts := jbscript.DefaultFormatOptions()
+	opts.Indent = strings.Repeat(" ", *indent)
+	if *crlf {
+		opts.LineEnding = "\r\n"
+	}
+
+	unformatted := false
+	err := filepath.WalkDir(flags.Arg(0), func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".jb") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		formatted, err := jbscript.Format(string(data), opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err.Error())
+			unformatted = true
+			return nil
+		}
+		if formatted == string(data) {
+			return nil
+		}
+
+		if !*write {
+			fmt.Println(path)
+			unformatted = true
+			return nil
+		}
+		return os.WriteFile(path, []byte(formatted), os.ModePerm)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if unformatted {
+		return 1
+	}
+	return 0
+}
+
+const lspUsage = "lsp [-root <extraction dir>]"
+
+// runLsp starts the language server over stdin and stdout.
+func runLsp(app *App, args []string) int {
+	flags := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	root := flags.String("root", "", "extraction directory, defaults to the workspace root")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], lspUsage)
+		return 2
+	}
+
+	if err := lsp.Serve(os.Stdin, os.Stdout, *root, app.pathSep); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+const builtinsUsage = "builtins [-csv] <extraction dir>"
+
+// runBuiltins prints the built-in functions used by each Jitterbit script.
+func runBuiltins(app *App, args []string) int {
+	flags := flag.NewFlagSet("builtins", flag.ContinueOnError)
+	asCSV := flags.Bool("csv", false, "print one script,function,category,calls,deprecated row per function")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], builtinsUsage)
+		return 2
+	}
+
+	cat := catalog.Default()
+	inventory, err := cat.InventoryDir(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *asCSV {
+		writer := csv.NewWriter(os.Stdout)
+		writer.Write([]string{"script", "function", "category", "calls", "deprecated"})
+		for _, inv := range inventory {
+			for _, usage := range inv.Usages {
+				fn := usage.Function
+				writer.Write([]string{inv.File, fn.Name, fn.Category, fmt.Sprint(len(usage.Positions)), fmt.Sprint(fn.Deprecated)})
+			}
+		}
+		writer.Flush()
+		return 0
+	}
+
+	fmt.Printf("Built-in function catalog %s\n", cat.Version)
+	for _, inv := range inventory {
+		fmt.Printf("\n%s\n", inv.File)
+		for _, usage := range inv.Usages {
+			fn := usage.Function
+			line := fmt.Sprintf("  %-32s %-16s %d", fn.Name, fn.Category, len(usage.Positions))
+			if fn.Deprecated {
+				line += " (deprecated)"
+			}
+			fmt.Println(line)
+		}
+	}
+	return 0
+}
+
+const globalsUsage = "globals [-csv] <project dir> <environment>"
+
+// runGlobals prints the cross-reference of global variables in scripts and transformation mappings.
+func runGlobals(app *App, args []string) int {
+	flags := flag.NewFlagSet("globals", flag.ContinueOnError)
+	asCSV := flags.Bool("csv", false, "print one variable,access,type,folder,entity,line row per location")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], globalsUsage)
+		return 2
+	}
+
+	envPath := fmt.Sprintf("%s%s%s", flags.Arg(0), app.pathSep, flags.Arg(1))
+	project, err := jbproj.ParseProject(envPath, app.pathSep)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	report, err := xref.Globals(project, app.pathSep)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *asCSV {
+		writer := csv.NewWriter(os.Stdout)
+		writer.Write([]string{"variable", "access", "type", "folder", "entity", "line"})
+		row := func(v *xref.Variable, access string, loc *xref.Location) {
+			line := ""
+			if loc.Line > 0 {
+				line = fmt.Sprint(loc.Line)
+			}
+			writer.Write([]string{"$" + v.Name, access, loc.Type, loc.Folder, loc.Entity, line})
+		}
+		for _, v := range report.Variables {
+			if v.Definition != nil {
+				row(v, "definition", v.Definition)
+			}
+			for _, loc := range v.Writes {
+				row(v, "write", loc)
+			}
+			for _, loc := range v.Reads {
+				row(v, "read", loc)
+			}
+		}
+		writer.Flush()
+		return 0
+	}
+
+	location := func(loc *xref.Location) string {
+		path := loc.Entity
+		if loc.Folder != "" {
+			path = fmt.Sprintf("%s/%s", loc.Folder, loc.Entity)
+		}
+		if loc.Line > 0 {
+			return fmt.Sprintf("%s %s:%d", loc.Type, path, loc.Line)
+		}
+		return fmt.Sprintf("%s %s", loc.Type, path)
+	}
+	for _, v := range report.Variables {
+		header := "$" + v.Name
+		switch {
+		case v.Unwritten():
+			header += " (read but never written)"
+		case v.Unread():
+			header += " (written but never read)"
+		}
+		fmt.Println(header)
+		if v.Definition != nil {
+			fmt.Printf("  defined  %s\n", location(v.Definition))
+		}
+		for _, loc := range v.Writes {
+			fmt.Printf("  write    %s\n", location(loc))
+		}
+		for _, loc := range v.Reads {
+			fmt.Printf("  read     %s\n", location(loc))
+		}
+	}
+	return 0
+}
+
+const testUsage = "test [-root <extraction dir>] <test file or dir>"
+
+// runTest executes Jitterbit script unit tests with the interpreter.
+func runTest(app *App, args []string) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	root := flags.String("root", ".", "extraction directory containing the tested scripts")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], testUsage)
+		return 2
+	}
+
+	results, err := interp.RunTests(flags.Arg(0), *root, app.pathSep)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("FAIL %s: %s: %s\n", result.File, result.Name, result.Err.Error())
+		} else {
+			fmt.Printf("ok   %s: %s\n", result.File, result.Name)
+		}
+	}
+	fmt.Printf("%d passed, %d failed\n", len(results)-failed, failed)
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+const migrateUsage = "migrate [-o <output dir>] <extraction dir>"
+
+// runMigrate converts extracted Jitterbit scripts to JavaScript and writes a migration report.
+func runMigrate(app *App, args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	out := flags.String("o", "", "output directory, defaults to <extraction dir>/JavaScript")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], migrateUsage)
+		return 2
+	}
+
+	root := flags.Arg(0)
+	if *out == "" {
+		*out = fmt.Sprintf("%s%sJavaScript", root, app.pathSep)
+	}
+
+	migrations, err := transpile.MigrateDir(root, *out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err := os.MkdirAll(*out, os.ModePerm); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	reportPath := fmt.Sprintf("%s%smigration-report.md", *out, app.pathSep)
+	if err := os.WriteFile(reportPath, []byte(transpile.Report(migrations)), os.ModePerm); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	for _, m := range migrations {
+		fmt.Printf("%-12s %s\n", m.Status(), m.File)
+	}
+	fmt.Printf("Report written to %s\n", reportPath)
+	return 0
+}
+
+const secretsUsage = "secrets [-allowlist allowlist.json] [-csv] <project dir> <environment>"
+
+// runSecrets reports hardcoded secrets in scripts, operations and unencrypted entity properties.
+func runSecrets(app *App, args []string) int {
+	flags := flag.NewFlagSet("secrets", flag.ContinueOnError)
+	allowlistPath := flags.String("allowlist", "", "JSON file with accepted findings, e.g. {\"fingerprints\": [\"3f1c...\"], \"entities\": [\"Script/Tests/*\"]}")
+	asCSV := flags.Bool("csv", false, "print one rule,type,folder,entity,property,line,secret,fingerprint row per finding")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], secretsUsage)
+		return 2
+	}
+
+	envPath := fmt.Sprintf("%s%s%s", flags.Arg(0), app.pathSep, flags.Arg(1))
+	project, err := jbproj.ParseProject(envPath, app.pathSep)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	findings, err := secrets.Scan(project, app.pathSep)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *allowlistPath != "" {
+		allowlist, err := secrets.LoadAllowlist(*allowlistPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		findings = allowlist.Filter(findings)
+	}
+
+	if *asCSV {
+		writer := csv.NewWriter(os.Stdout)
+		writer.Write([]string{"rule", "type", "folder", "entity", "property", "line", "secret", "fingerprint"})
+		for _, f := range findings {
+			writer.Write([]string{f.Rule, f.Type, f.Folder, f.Entity, f.Property, fmt.Sprint(f.Line), f.Redacted(), f.Fingerprint()})
+		}
+		writer.Flush()
+	} else {
+		for _, f := range findings {
+			location := fmt.Sprintf("%s:%d", f.Path(), f.Line)
+			if f.Property != "" {
+				location = fmt.Sprintf("%s property %s", location, f.Property)
+			}
+			fmt.Printf("%s: %s: %s [%s]\n", location, f.Rule, f.Redacted(), f.Fingerprint())
+		}
+	}
+
+	if len(findings) > 0 {
+		return 1
+	}
+	return 0
+}
+
+const anonymizeUsage = "anonymize [-map <csv file>] <project dir> <environment> <output dir>"
+
+// runAnonymize writes a shareable copy of a project environment with pseudonymized names and scrubbed scripts.
+func runAnonymize(app *App, args []string) int {
+	flags := flag.NewFlagSet("anonymize", flag.ContinueOnError)
+	mapPath := flags.String("map", "", "write the type,id,name,pseudonym mapping to a CSV file, which must not be shared")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 3 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], anonymizeUsage)
+		return 2
+	}
+
+	result, err := anonymize.Anonymize(flags.Arg(0), flags.Arg(1), flags.Arg(2), app.pathSep)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *mapPath != "" {
+		file, err := os.Create(*mapPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer file.Close()
+		writer := csv.NewWriter(file)
+		writer.Write([]string{"type", "id", "name", "pseudonym"})
+		for _, p := range result.Pseudonyms {
+			writer.Write([]string{p.Type, p.Id, p.Name, p.Pseudonym})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	fmt.Printf("%d names replaced, anonymized environment written to %s\n", len(result.Pseudonyms), result.EnvPath)
+	return 0
+}
+
+const docsUsage = "docs [-title <title>] <project dir> <environment> <output dir>"
+
+// runDocs generates a static HTML documentation site of a project environment.
+func runDocs(app *App, args []string) int {
+	flags := flag.NewFlagSet("docs", flag.ContinueOnError)
+	title := flags.String("title", "", "site title, defaults to the project and environment name")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 3 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], docsUsage)
+		return 2
+	}
+
+	envPath := fmt.Sprintf("%s%s%s", flags.Arg(0), app.pathSep, flags.Arg(1))
+	project, err := jbproj.ParseProject(envPath, app.pathSep)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *title == "" {
+		*title = fmt.Sprintf("%s %s", project.Name, flags.Arg(1))
+	}
+
+	out := flags.Arg(2)
+	if err := os.MkdirAll(out, os.ModePerm); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err := docs.Generate(project, *title, out, app.pathSep); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	fmt.Printf("Documentation written to %s%sindex.html\n", out, app.pathSep)
+	return 0
+}
+
+const unannotateUsage = "unannotate <operation xml or extraction dir>"
+
+// runUnannotate removes the entity annotations from extracted operation XML files to restore the original files.
+func runUnannotate(app *App, args []string) int {
+	flags := flag.NewFlagSet("unannotate", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], unannotateUsage)
+		return 2
+	}
+
+	restored := 0
+	err := filepath.WalkDir(flags.Arg(0), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".xml") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		stripped := operation.StripAnnotations(data)
+		if len(stripped) == len(data) {
+			return nil
+		}
+		restored++
+		return os.WriteFile(path, stripped, os.ModePerm)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	fmt.Printf("%d files restored\n", restored)
+	return 0
+}
@@ -1,10 +1,15 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {main} from '../models';
 
-export function Extract(arg1:string,arg2:string,arg3:string):Promise<boolean>;
+export function Extract(arg1:string,arg2:string,arg3:string,arg4:boolean,arg5:boolean,arg6:boolean):Promise<boolean>;
+
+export function GetEntityContent(arg1:string,arg2:string,arg3:string):Promise<main.EntityPreview>;
 
 export function GetEnvs(arg1:string):Promise<Array<string>>;
 
+export function SearchScripts(arg1:string,arg2:string,arg3:string):Promise<Array<main.SearchResult>>;
+
 export function SelectOutput():Promise<string>;
 
 export function SelectProject():Promise<string>;
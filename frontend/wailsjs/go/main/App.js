@@ -2,14 +2,22 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function Extract(arg1, arg2, arg3) {
-  return window['go']['main']['App']['Extract'](arg1, arg2, arg3);
+export function Extract(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['Extract'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function GetEntityContent(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetEntityContent'](arg1, arg2, arg3);
 }
 
 export function GetEnvs(arg1) {
   return window['go']['main']['App']['GetEnvs'](arg1);
 }
 
+export function SearchScripts(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SearchScripts'](arg1, arg2, arg3);
+}
+
 export function SelectOutput() {
   return window['go']['main']['App']['SelectOutput']();
 }
@@ -0,0 +1,75 @@
+export namespace main {
+	
+	export class EntityPreview {
+	    id: string;
+	    name: string;
+	    type: string;
+	    folder: string;
+	    content: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new EntityPreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.folder = source["folder"];
+	        this.content = source["content"];
+	    }
+	}
+	export class LineMatch {
+	    line: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LineMatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.text = source["text"];
+	    }
+	}
+	export class SearchResult {
+	    id: string;
+	    name: string;
+	    folder: string;
+	    matches: LineMatch[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.folder = source["folder"];
+	        this.matches = this.convertValues(source["matches"], LineMatch);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
@@ -0,0 +1,350 @@
+package anonymize
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"jbextractor/jitterbit/entity"
+	jbproj "jbextractor/jitterbit/project"
+	"jbextractor/jitterbit/secrets"
+)
+
+// Names of the anonymized project and environment.
+const (
+	PROJECT_NAME     = "Project"
+	ENVIRONMENT_NAME = "Environment"
+)
+
+// A replaced folder or entity name.
+type Pseudonym struct {
+	// Entity type, Folder for folders.
+	Type      string
+	Id        string
+	Name      string
+	Pseudonym string
+}
+
+// Outcome of an anonymization.
+type Result struct {
+	// Path of the anonymized environment, which can be passed to ParseProject.
+	EnvPath string
+	// Replaced names in project.xml order.
+	Pseudonyms []*Pseudonym
+}
+
+// Anonymizer state shared by all files of an environment.
+type anonymizer struct {
+	// Pseudonyms by entity or folder ID.
+	names    map[string]string
+	scrubber *scrubber
+	result   *Result
+}
+
+// Anonymize writes a shareable copy of a project environment to the output directory: folder and entity names
+// are replaced by pseudonyms, string literals and comments of scripts and mappings are scrubbed, and encrypted
+// property values and unencrypted values detected as secrets are removed. IDs are preserved, project variables keep their names as scripts refer to them.
+func Anonymize(projectPath string, env string, out string, sep string) (*Result, error) {
+	envPath := fmt.Sprintf("%s%s%s", projectPath, sep, env)
+	project, err := jbproj.ParseProject(envPath, sep)
+	if err != nil {
+		return nil, err
+	}
+
+	outEnv := fmt.Sprintf("%s%s%s", out, sep, ENVIRONMENT_NAME)
+	a := &anonymizer{
+		names:    make(map[string]string),
+		scrubber: newScrubber(),
+		result:   &Result{EnvPath: outEnv, Pseudonyms: []*Pseudonym{}},
+	}
+	a.assignNames(project)
+
+	if err := os.MkdirAll(outEnv, os.ModePerm); err != nil {
+		return nil, err
+	}
+	err = copyProperties(fmt.Sprintf("%s%smanifest.jip", projectPath, sep), fmt.Sprintf("%s%smanifest.jip", out, sep), "project-name", PROJECT_NAME)
+	if err != nil {
+		return nil, err
+	}
+	err = copyProperties(fmt.Sprintf("%s%senvironment.properties", envPath, sep), fmt.Sprintf("%s%senvironment.properties", outEnv, sep), "environment-name", ENVIRONMENT_NAME)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.rewriteFile(envPath, outEnv, "project.xml", a.rewriteProject); err != nil {
+		return nil, err
+	}
+
+	dataPath := fmt.Sprintf("%s%sData", envPath, sep)
+	err = filepath.WalkDir(dataPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(envPath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(outEnv, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, os.ModePerm)
+		case strings.HasSuffix(path, ".xml"):
+			return a.rewriteFile(envPath, outEnv, rel, a.rewriteEntity)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, os.ModePerm)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.result, nil
+}
+
+// assignNames numbers folders and entities of each type in project.xml order, e.g. Folder 1 and Script 1.
+func (a *anonymizer) assignNames(project *jbproj.Project) {
+	folderCount := 0
+	for _, et := range project.EntityTypes {
+		if et.Name == jbproj.VARIABLE {
+			continue
+		}
+
+		entityCount := 0
+		var addEntities func(entities []jbproj.Entity, path string)
+		addEntities = func(entities []jbproj.Entity, path string) {
+			for _, ent := range entities {
+				entityCount++
+				a.assign(et.Name, ent.Id, ent.Name, fmt.Sprintf("%s/%s", path, ent.Name), fmt.Sprintf("%s %d", et.Name, entityCount))
+			}
+		}
+		var addFolders func(folders []jbproj.Folder, path string)
+		addFolders = func(folders []jbproj.Folder, path string) {
+			for _, folder := range folders {
+				folderCount++
+				folderPath := fmt.Sprintf("%s/%s", path, folder.Name)
+				a.assign("Folder", folder.Id, folder.Name, folderPath, fmt.Sprintf("Folder %d", folderCount))
+				addEntities(folder.Entities, folderPath)
+				addFolders(folder.Subfolders, folderPath)
+			}
+		}
+		// root of <TAG> paths, e.g. Scripts
+		root := fmt.Sprintf("%ss", et.Name)
+		addFolders(et.Folders, root)
+		addEntities(et.Entities, root)
+	}
+}
+
+// assign records the pseudonym of a folder or entity with its <TAG> path.
+func (a *anonymizer) assign(typeName string, id string, name string, path string, pseudonym string) {
+	a.names[id] = pseudonym
+	a.scrubber.paths[path] = pseudonym
+	a.result.Pseudonyms = append(a.result.Pseudonyms, &Pseudonym{Type: typeName, Id: id, Name: name, Pseudonym: pseudonym})
+}
+
+// copyProperties copies a key=value file with the value of the key replaced.
+func copyProperties(src string, dst string, key string, value string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, key+"=") {
+			line = fmt.Sprintf("%s=%s", key, value)
+		}
+		content.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, []byte(content.String()), os.ModePerm)
+}
+
+// rewriteFile writes the rewritten XML file with the relative path to the output environment.
+func (a *anonymizer) rewriteFile(envPath string, outEnv string, rel string, rewrite func(data []byte) ([]byte, error)) error {
+	data, err := os.ReadFile(filepath.Join(envPath, rel))
+	if err != nil {
+		return err
+	}
+	result, err := rewrite(data)
+	if err != nil {
+		return fmt.Errorf("[Anonymize] %s: %s", rel, err.Error())
+	}
+	return os.WriteFile(filepath.Join(outEnv, rel), result, os.ModePerm)
+}
+
+// rewriteProject replaces the project, folder and entity names in project.xml.
+func (a *anonymizer) rewriteProject(data []byte) ([]byte, error) {
+	edits := []edit{}
+	err := walkXML(data, func(token xml.Token, start int, end int, stack []string) {
+		elem, ok := token.(xml.StartElement)
+		if !ok {
+			return
+		}
+		raw := string(data[start:end])
+		switch elem.Name.Local {
+		case "Project":
+			edits = append(edits, edit{start, end, setAttr(raw, "name", PROJECT_NAME)})
+		case "Folder", "Entity":
+			if pseudonym, ok := a.names[entity.AttrValue(elem, "entityId")]; ok {
+				edits = append(edits, edit{start, end, setAttr(raw, "name", pseudonym)})
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applyEdits(data, edits), nil
+}
+
+// rewriteEntity replaces the entity name, scrubs scripts and mappings, removes encrypted and secret property values
+// and replaces host names, user names and email addresses in the other property values.
+func (a *anonymizer) rewriteEntity(data []byte) ([]byte, error) {
+	edits := []edit{}
+	isScript := false
+	// script content of Entity/konga.string
+	scriptStart := -1
+	var scriptText strings.Builder
+
+	err := walkXML(data, func(token xml.Token, start int, end int, stack []string) {
+		path := strings.Join(append(append([]string{}, stack...), elementName(token)), "/")
+		switch t := token.(type) {
+		case xml.StartElement:
+			raw := string(data[start:end])
+			switch {
+			case path == "Entity":
+				isScript = entity.AttrValue(t, "type") == jbproj.SCRIPT
+			case path == "Entity/konga.string" && isScript:
+				scriptStart = end
+				scriptText.Reset()
+			case t.Name.Local == "Header":
+				if pseudonym, ok := a.names[entity.AttrValue(t, "ID")]; ok {
+					raw = setAttr(raw, "Name", pseudonym)
+				}
+			case t.Name.Local == "Item":
+				key, value := entity.AttrValue(t, "key"), entity.AttrValue(t, "value")
+				if entity.AttrValue(t, "enc") == "true" || secrets.IsSecretProperty(key, value) {
+					raw = setAttr(raw, "value", "")
+				} else if replaced := a.scrubber.property(key, value); replaced != value {
+					raw = setAttr(raw, "value", replaced)
+				}
+			}
+			for _, attr := range t.Attr {
+				if strings.Contains(attr.Value, "<trans>") {
+					raw = setAttr(raw, attr.Name.Local, a.scrubber.scrub(attr.Value))
+				}
+			}
+			if raw != string(data[start:end]) {
+				edits = append(edits, edit{start, end, raw})
+			}
+		case xml.CharData:
+			switch {
+			case scriptStart >= 0:
+				scriptText.Write(t)
+			case strings.Contains(string(t), "<trans>"):
+				edits = append(edits, edit{start, end, escapeText(a.scrubber.scrub(string(t)), data[start:end])})
+			}
+		case xml.EndElement:
+			if path == "Entity/konga.string" && scriptStart >= 0 {
+				edits = append(edits, edit{scriptStart, start, escapeText(a.scrubber.scrub(scriptText.String()), data[scriptStart:start])})
+				scriptStart = -1
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applyEdits(data, edits), nil
+}
+
+// walkXML calls visit for each token with its byte span and the names of the enclosing elements.
+func walkXML(data []byte, visit func(token xml.Token, start int, end int, stack []string)) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	stack := []string{}
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		end := int(decoder.InputOffset())
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			visit(t, start, end, stack)
+			stack = append(stack, t.Name.Local)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			visit(t, start, end, stack)
+		default:
+			visit(t, start, end, stack)
+		}
+	}
+}
+
+// elementName returns the local name of start and end elements, empty for other tokens.
+func elementName(token xml.Token) string {
+	switch t := token.(type) {
+	case xml.StartElement:
+		return t.Name.Local
+	case xml.EndElement:
+		return t.Name.Local
+	}
+	return ""
+}
+
+// A replacement of a byte span.
+type edit struct {
+	start int
+	end   int
+	text  string
+}
+
+// applyEdits returns the data with ordered, non-overlapping edits applied.
+func applyEdits(data []byte, edits []edit) []byte {
+	var result bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		result.Write(data[last:e.start])
+		result.WriteString(e.text)
+		last = e.end
+	}
+	result.Write(data[last:])
+	return result.Bytes()
+}
+
+// setAttr replaces an attribute value in the source text of a start tag.
+func setAttr(raw string, name string, value string) string {
+	regex := regexp.MustCompile(`(\s(?:[\w.-]+:)?` + regexp.QuoteMeta(name) + `\s*=\s*)(?:"[^"]*"|'[^']*')`)
+	escaped := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;", "\n", "&#xA;", "\r", "&#xD;").Replace(value)
+	loc := regex.FindStringSubmatchIndex(raw)
+	if loc == nil {
+		return raw
+	}
+	return raw[:loc[3]] + "\"" + escaped + "\"" + raw[loc[1]:]
+}
+
+// escapeText escapes character data, keeping the CRLF line endings of the original source text.
+func escapeText(text string, original []byte) string {
+	escaped := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+	if bytes.Contains(original, []byte("\r\n")) {
+		escaped = strings.ReplaceAll(escaped, "\n", "\r\n")
+	}
+	return escaped
+}
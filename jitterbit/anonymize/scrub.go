@@ -0,0 +1,185 @@
+package anonymize
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"jbextractor/jitterbit/javascript"
+	"jbextractor/jitterbit/script"
+	"jbextractor/jitterbit/xref"
+)
+
+var (
+	// Global variable names passed as strings, e.g. Jitterbit.GetVar("$name").
+	globalName = regexp.MustCompile(`^\$[A-Za-z_][\w.]*$`)
+	// URLs or email addresses in property values.
+	addressValue = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s"'<>,;]+|[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+`)
+	// Host names with an optional port, e.g. sap.example.com:3300.
+	hostValue = regexp.MustCompile(`^([A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+)(:\d+)?$`)
+	// Property keys of host and user names.
+	hostKey = regexp.MustCompile(`(?i)host|server|domain`)
+	userKey = regexp.MustCompile(`(?i)^(user|login|account)(.?(name|id))?$`)
+)
+
+// Consistent replacements of string literal values.
+type scrubber struct {
+	values map[string]string
+	// Pseudonyms of folders and entities by <TAG> path, e.g. Scripts/Folder/Name.
+	paths map[string]string
+}
+
+func newScrubber() *scrubber {
+	return &scrubber{values: make(map[string]string), paths: make(map[string]string)}
+}
+
+// keep reports literals required to reproduce the project structure: entity IDs and global variable names.
+func keep(value string) bool {
+	_, id, _, _ := script.ParseRefValue(value)
+	return value == "" || id != "" || globalName.MatchString(value)
+}
+
+// literal returns the replacement of a string literal with the same quotes and line count.
+// <TAG> paths are rewritten with the pseudonyms of their folders and entity.
+func (s *scrubber) literal(raw string, value string) string {
+	quote := raw[:1]
+	if _, _, tag, dynamic := script.ParseRefValue(value); !dynamic && tag != "" {
+		return quote + "<TAG>" + s.tagPath(tag) + "</TAG>" + quote
+	}
+	if keep(value) {
+		return raw
+	}
+	return quote + s.pseudonym(value) + strings.Repeat("\n", strings.Count(raw, "\n")) + quote
+}
+
+// pseudonym returns the consistent replacement of a literal value.
+func (s *scrubber) pseudonym(value string) string {
+	pseudonym, ok := s.values[value]
+	if !ok {
+		pseudonym = fmt.Sprintf("str%d", len(s.values)+1)
+		s.values[value] = pseudonym
+	}
+	return pseudonym
+}
+
+// host returns the pseudonym of a host name in the reserved .example domain, localhost is kept.
+func (s *scrubber) host(name string) string {
+	if name == "" || strings.EqualFold(name, "localhost") {
+		return name
+	}
+	return s.pseudonym(strings.ToLower(name)) + ".example"
+}
+
+// property replaces the host and user names of URLs, email addresses and host and user name properties.
+// Other property values are returned unchanged.
+func (s *scrubber) property(key string, value string) string {
+	replaced := addressValue.ReplaceAllStringFunc(value, func(address string) string {
+		if !strings.Contains(address, "://") {
+			at := strings.LastIndex(address, "@")
+			return s.pseudonym(address[:at]) + "@" + s.host(address[at+1:])
+		}
+		parsed, err := url.Parse(address)
+		if err != nil || parsed.Host == "" {
+			return address
+		}
+		if port := parsed.Port(); port != "" {
+			parsed.Host = s.host(parsed.Hostname()) + ":" + port
+		} else {
+			parsed.Host = s.host(parsed.Hostname())
+		}
+		if parsed.User == nil {
+			return parsed.String()
+		}
+		_, hasPassword := parsed.User.Password()
+		parsed.User = url.User(s.pseudonym(parsed.User.Username()))
+		if !hasPassword {
+			return parsed.String()
+		}
+		// the pseudonym contains no @
+		return strings.Replace(parsed.String(), "@", ":****@", 1)
+	})
+	if replaced != value {
+		return replaced
+	}
+
+	switch match := hostValue.FindStringSubmatch(value); {
+	case match != nil && hostKey.MatchString(key):
+		return s.host(match[1]) + match[2]
+	case userKey.MatchString(key) && !keep(value) && !strings.ContainsAny(value, "[$"):
+		return s.pseudonym(value)
+	}
+	return value
+}
+
+// tagPath replaces the folder and entity names of a <TAG> path by their pseudonyms,
+// names missing from the project are replaced like literals.
+func (s *scrubber) tagPath(tag string) string {
+	segments := strings.Split(tag, "/")
+	path := segments[0]
+	for idx := 1; idx < len(segments); idx++ {
+		path = fmt.Sprintf("%s/%s", path, segments[idx])
+		if pseudonym, ok := s.paths[path]; ok {
+			segments[idx] = pseudonym
+		} else {
+			segments[idx] = s.pseudonym(segments[idx])
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// comment returns an empty comment with the same line count.
+func comment(raw string) string {
+	if strings.HasPrefix(raw, "/*") {
+		return "/*" + strings.Repeat("\n", strings.Count(raw, "\n")) + "*/"
+	}
+	return "//"
+}
+
+// scrub replaces string literals and comments of a Jitterbit Script, a <javascript> script or a mapping with <trans> blocks.
+func (s *scrubber) scrub(src string) string {
+	if code, ok := xref.JavaScriptCode(src); ok {
+		// whitespace around the tags is kept
+		trimmed := strings.TrimSpace(src)
+		lead := src[:strings.Index(src, trimmed)]
+		return lead + xref.JS_OPEN_TAG + s.javaScript(code) + xref.JS_CLOSE_TAG + src[len(lead)+len(trimmed):]
+	}
+
+	tokens, _ := script.Tokenize(src)
+	return s.jitterbitScript(src, tokens)
+}
+
+// jitterbitScript applies the replacements to the tokens of a Jitterbit Script.
+func (s *scrubber) jitterbitScript(src string, tokens []script.Token) string {
+	edits := []script.Edit{}
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case script.STRING:
+			edits = append(edits, script.Edit{Pos: tok.Pos, End: tok.End, Text: s.literal(tok.Text, tok.Value)})
+		case script.COMMENT:
+			edits = append(edits, script.Edit{Pos: tok.Pos, End: tok.End, Text: comment(tok.Text)})
+		case script.TEXT:
+			// text outside of <trans> blocks is not executed
+			edits = append(edits, script.Edit{Pos: tok.Pos, End: tok.End, Text: strings.Repeat("\n", strings.Count(tok.Text, "\n"))})
+		}
+	}
+	return script.ApplyEdits(src, edits)
+}
+
+// javaScript applies the replacements to JavaScript code. Code which cannot be parsed is replaced
+// by empty lines as its literals cannot be found reliably.
+func (s *scrubber) javaScript(code string) string {
+	prog, errs := javascript.Parse(code)
+	if len(errs) > 0 {
+		return strings.Repeat("\n", strings.Count(code, "\n"))
+	}
+
+	edits := []script.Edit{}
+	for _, lit := range prog.Strings {
+		edits = append(edits, script.Edit{Pos: lit.Pos, End: lit.End, Text: s.literal(lit.Raw, lit.Value)})
+	}
+	for _, c := range prog.Comments {
+		edits = append(edits, script.Edit{Pos: c.Pos, End: c.End, Text: comment(c.Text)})
+	}
+	return script.ApplyEdits(code, edits)
+}
@@ -0,0 +1,140 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Built-in function definitions, versioned with the catalog format.
+//
+//go:embed functions.json
+var functionsJSON []byte
+
+// A function parameter.
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Can be omitted, together with all following parameters.
+	Optional bool `json:"optional,omitempty"`
+	// Repeats, always the last parameter.
+	Variadic bool `json:"variadic,omitempty"`
+}
+
+// A Jitterbit Script built-in function.
+type Function struct {
+	Name        string  `json:"name"`
+	Category    string  `json:"category"`
+	Params      []Param `json:"params"`
+	Returns     string  `json:"returns"`
+	Description string  `json:"description"`
+	Deprecated  bool    `json:"deprecated,omitempty"`
+	// Function to use instead of a deprecated one.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// MinArgs returns the number of required arguments.
+func (fn *Function) MinArgs() int {
+	count := 0
+	for _, param := range fn.Params {
+		if !param.Optional {
+			count++
+		}
+	}
+	return count
+}
+
+// MaxArgs returns the maximum number of arguments, -1 for variadic functions.
+func (fn *Function) MaxArgs() int {
+	for _, param := range fn.Params {
+		if param.Variadic {
+			return -1
+		}
+	}
+	return len(fn.Params)
+}
+
+// Signature returns the function declaration, e.g. Left(str string, n int) string.
+func (fn *Function) Signature() string {
+	params := []string{}
+	for _, param := range fn.Params {
+		text := fmt.Sprintf("%s %s", param.Name, param.Type)
+		if param.Variadic {
+			text = fmt.Sprintf("%s... %s", param.Name, param.Type)
+		}
+		if param.Optional {
+			text = "[" + text + "]"
+		}
+		params = append(params, text)
+	}
+	return fmt.Sprintf("%s(%s) %s", fn.Name, strings.Join(params, ", "), fn.Returns)
+}
+
+// A set of built-in functions.
+type Catalog struct {
+	Version   string      `json:"version"`
+	Functions []*Function `json:"functions"`
+	byName    map[string]*Function
+}
+
+// Parse reads a catalog from its JSON representation.
+func Parse(data []byte) (*Catalog, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("[ParseCatalog] %s", err.Error())
+	}
+
+	catalog.byName = make(map[string]*Function)
+	for _, fn := range catalog.Functions {
+		if _, ok := catalog.byName[fn.Name]; ok {
+			return nil, fmt.Errorf("[ParseCatalog] Duplicate function %s", fn.Name)
+		}
+		for idx, param := range fn.Params {
+			if param.Variadic && idx != len(fn.Params)-1 {
+				return nil, fmt.Errorf("[ParseCatalog] Variadic parameter %s of %s is not the last one", param.Name, fn.Name)
+			}
+		}
+		catalog.byName[fn.Name] = fn
+	}
+	return &catalog, nil
+}
+
+var (
+	defaultCatalog *Catalog
+	defaultOnce    sync.Once
+)
+
+// Default returns the embedded catalog.
+func Default() *Catalog {
+	defaultOnce.Do(func() {
+		var err error
+		defaultCatalog, err = Parse(functionsJSON)
+		if err != nil {
+			panic(err)
+		}
+	})
+	return defaultCatalog
+}
+
+// Lookup returns the function with the name.
+func (catalog *Catalog) Lookup(name string) (*Function, bool) {
+	fn, ok := catalog.byName[name]
+	return fn, ok
+}
+
+// Categories returns the sorted function category names.
+func (catalog *Catalog) Categories() []string {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, fn := range catalog.Functions {
+		if !seen[fn.Category] {
+			seen[fn.Category] = true
+			names = append(names, fn.Category)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,80 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/script"
+)
+
+// Calls of a built-in function in a script.
+type Usage struct {
+	Function *Function
+	// Call positions in source order.
+	Positions []script.Pos
+}
+
+// Built-in functions used by a script file.
+type ScriptInventory struct {
+	File   string
+	Usages []*Usage
+}
+
+// Inventory returns the built-in functions called by the script, sorted by name. Unknown functions are skipped.
+func (catalog *Catalog) Inventory(parsed *script.Script) []*Usage {
+	usages := make(map[string]*Usage)
+	script.Inspect(parsed, func(node script.Node) bool {
+		call, ok := node.(*script.Call)
+		if !ok {
+			return true
+		}
+		fn, ok := catalog.Lookup(call.Name.Name)
+		if !ok {
+			return true
+		}
+
+		usage, ok := usages[fn.Name]
+		if !ok {
+			usage = &Usage{Function: fn}
+			usages[fn.Name] = usage
+		}
+		usage.Positions = append(usage.Positions, call.Pos())
+		return true
+	})
+
+	result := []*Usage{}
+	for _, usage := range usages {
+		result = append(result, usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Function.Name < result[j].Function.Name
+	})
+	return result
+}
+
+// InventoryDir returns the built-in functions used by each Jitterbit Script file of an extraction directory.
+func (catalog *Catalog) InventoryDir(root string) ([]*ScriptInventory, error) {
+	result := []*ScriptInventory{}
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".jb") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		parsed, _ := script.Parse(string(data))
+		result = append(result, &ScriptInventory{File: path, Usages: catalog.Inventory(parsed)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
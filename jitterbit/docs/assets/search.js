@@ -0,0 +1,65 @@
+// Client-side search over SEARCH_INDEX from search-index.js.
+(function () {
+  var MAX_RESULTS = 50;
+  var input = document.getElementById("search");
+  var results = document.getElementById("results");
+  var root = document.body.getAttribute("data-root");
+
+  function matches(entry, terms) {
+    var name = (entry.name + " " + entry.path).toLowerCase();
+    var text = entry.text.toLowerCase();
+    var inName = true;
+    for (var i = 0; i < terms.length; i++) {
+      if (name.indexOf(terms[i]) < 0) {
+        inName = false;
+        if (text.indexOf(terms[i]) < 0) {
+          return 0;
+        }
+      }
+    }
+    // name matches first
+    return inName ? 2 : 1;
+  }
+
+  function search() {
+    results.innerHTML = "";
+    var terms = input.value.toLowerCase().split(/\s+/).filter(function (t) { return t !== ""; });
+    if (terms.length === 0) {
+      return;
+    }
+
+    var found = [];
+    for (var i = 0; i < SEARCH_INDEX.length; i++) {
+      var rank = matches(SEARCH_INDEX[i], terms);
+      if (rank > 0) {
+        found.push({ rank: rank, entry: SEARCH_INDEX[i] });
+      }
+    }
+    found.sort(function (a, b) { return b.rank - a.rank; });
+
+    for (var j = 0; j < found.length && j < MAX_RESULTS; j++) {
+      var entry = found[j].entry;
+      var item = document.createElement("li");
+      var link = document.createElement("a");
+      link.href = root + entry.url;
+      link.textContent = entry.name;
+      var path = document.createElement("div");
+      path.className = "path";
+      path.textContent = entry.type + "/" + entry.path + (found[j].rank === 1 ? " (in script)" : "");
+      item.appendChild(link);
+      item.appendChild(path);
+      results.appendChild(item);
+    }
+  }
+
+  input.addEventListener("input", search);
+  input.addEventListener("keydown", function (event) {
+    if (event.key === "Enter" && results.firstChild) {
+      window.location.href = results.firstChild.firstChild.href;
+    }
+    if (event.key === "Escape") {
+      input.value = "";
+      search();
+    }
+  });
+})();
@@ -0,0 +1,148 @@
+package docs
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/catalog"
+	"jbextractor/jitterbit/javascript"
+	"jbextractor/jitterbit/script"
+)
+
+// A highlighted source range, linked if href is set.
+type span struct {
+	start int
+	end   int
+	class string
+	href  string
+	title string
+}
+
+var (
+	jsKeyword = regexp.MustCompile(`\b(?:break|case|catch|continue|default|delete|do|else|false|finally|for|function|if|in|instanceof|new|null|return|switch|this|throw|true|try|typeof|undefined|var|void|while|with)\b`)
+	jsNumber  = regexp.MustCompile(`\b(?:0[xX][0-9a-fA-F]+|[0-9]+(?:\.[0-9]*)?(?:[eE][+-]?[0-9]+)?)\b`)
+)
+
+// render returns the escaped source with the spans as HTML elements. Overlapping spans are dropped.
+func render(src string, spans []span) template.HTML {
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var out strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last || s.end <= s.start {
+			continue
+		}
+		out.WriteString(html.EscapeString(src[last:s.start]))
+		text := html.EscapeString(src[s.start:s.end])
+		title := ""
+		if s.title != "" {
+			title = fmt.Sprintf(` title="%s"`, html.EscapeString(s.title))
+		}
+		if s.href != "" {
+			fmt.Fprintf(&out, `<a class="%s" href="%s"%s>%s</a>`, s.class, html.EscapeString(s.href), title, text)
+		} else {
+			fmt.Fprintf(&out, `<span class="%s"%s>%s</span>`, s.class, title, text)
+		}
+		last = s.end
+	}
+	out.WriteString(html.EscapeString(src[last:]))
+	return template.HTML(out.String())
+}
+
+// highlightScript highlights Jitterbit Script, references are linked with the href of their entity ID.
+func highlightScript(src string, href func(id string) string) template.HTML {
+	parsed, _ := script.Parse(src)
+	links := make(map[int]string)
+	for _, ref := range script.FindReferences(parsed) {
+		if ref.Id != "" {
+			links[ref.Literal.Pos().Offset] = href(ref.Id)
+		}
+	}
+
+	tokens, _ := script.Tokenize(src)
+	spans := []span{}
+	for idx, tok := range tokens {
+		s := span{start: tok.Pos.Offset, end: tok.End.Offset}
+		switch tok.Kind {
+		case script.TEXT:
+			s.class = "text"
+		case script.TRANS_OPEN, script.TRANS_CLOSE:
+			s.class = "tag"
+		case script.COMMENT:
+			s.class = "comment"
+		case script.STRING:
+			s.class = "string"
+			if link := links[tok.Pos.Offset]; link != "" {
+				s.class = "string ref"
+				s.href = link
+			}
+		case script.NUMBER:
+			s.class = "number"
+		case script.GLOBAL:
+			s.class = "global"
+		case script.IDENT:
+			if idx+1 >= len(tokens) || tokens[idx+1].Kind != script.LPAREN {
+				continue
+			}
+			s.class = "function"
+			if fn, ok := catalog.Default().Lookup(tok.Text); ok {
+				s.title = fn.Signature()
+			}
+		default:
+			continue
+		}
+		spans = append(spans, s)
+	}
+	return render(src, spans)
+}
+
+// highlightJavaScript highlights JavaScript code, references are linked with the href of their entity ID.
+func highlightJavaScript(src string, href func(id string) string) template.HTML {
+	prog, _ := javascript.Parse(src)
+	links := make(map[int]string)
+	for _, ref := range javascript.FindReferences(prog) {
+		if ref.Id != "" {
+			links[ref.Literal.Pos.Offset] = href(ref.Id)
+		}
+	}
+
+	spans := []span{}
+	for _, lit := range prog.Strings {
+		s := span{start: lit.Pos.Offset, end: lit.End.Offset, class: "string"}
+		if link := links[lit.Pos.Offset]; link != "" {
+			s.class = "string ref"
+			s.href = link
+		}
+		spans = append(spans, s)
+	}
+	for _, c := range prog.Comments {
+		spans = append(spans, span{start: c.Pos.Offset, end: c.End.Offset, class: "comment"})
+	}
+
+	// keywords and numbers between strings and comments
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	gaps := [][2]int{}
+	last := 0
+	for _, s := range spans {
+		if s.start > last {
+			gaps = append(gaps, [2]int{last, s.start})
+		}
+		if s.end > last {
+			last = s.end
+		}
+	}
+	gaps = append(gaps, [2]int{last, len(src)})
+	for _, gap := range gaps {
+		for class, regex := range map[string]*regexp.Regexp{"keyword": jsKeyword, "number": jsNumber} {
+			for _, loc := range regex.FindAllStringIndex(src[gap[0]:gap[1]], -1) {
+				spans = append(spans, span{start: gap[0] + loc[0], end: gap[0] + loc[1], class: class})
+			}
+		}
+	}
+	return render(src, spans)
+}
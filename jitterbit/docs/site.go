@@ -0,0 +1,353 @@
+package docs
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/entity"
+	jbproj "jbextractor/jitterbit/project"
+	"jbextractor/jitterbit/secrets"
+	"jbextractor/jitterbit/xref"
+)
+
+//go:embed templates/*.html assets/*
+var files embed.FS
+
+// A documented folder or entity page.
+type Page struct {
+	Id   string
+	Name string
+	Type string
+	// Parent folder names.
+	Folders []string
+	// Page path relative to the site root, e.g. Script/<id>.html.
+	URL string
+	// Parsed entity file, nil if the project has no data for the entity.
+	Entity *entity.Entity
+	// Referenced entities, e.g. called scripts and operations or the activities of an operation.
+	Calls []*Page
+	// Entities referencing this entity.
+	CalledBy []*Page
+}
+
+// Path returns the slash-separated folder path including the entity name.
+func (page *Page) Path() string {
+	return strings.Join(append(append([]string{}, page.Folders...), page.Name), "/")
+}
+
+// A folder of the navigation tree.
+type navFolder struct {
+	Name    string
+	Folders []*navFolder
+	Pages   []*Page
+}
+
+// The navigation tree of an entity type.
+type navType struct {
+	Name  string
+	Count int
+	Root  *navFolder
+}
+
+// An operation step shown on the operation page.
+type activityView struct {
+	Role   string
+	Type   string
+	Target *Page
+	// Content ID of targets missing from the project.
+	ContentId string
+}
+
+// An entity property shown on the entity page.
+type propertyView struct {
+	Key    string
+	Value  string
+	Masked bool
+}
+
+// A search-index.js entry.
+type searchEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+	URL  string `json:"url"`
+	Text string `json:"text"`
+}
+
+// Site generator state.
+type site struct {
+	title     string
+	pages     map[string]*Page
+	types     []*navType
+	templates *template.Template
+}
+
+// Generate writes a self-contained static HTML site documenting the project environment to the output directory:
+// an index with the folder trees of all entity types, a page per entity, highlighted scripts with linked references,
+// back-links and client-side search.
+func Generate(project *jbproj.Project, title string, out string, sep string) error {
+	funcs := template.FuncMap{"inc": func(idx int) int { return idx + 1 }}
+	templates, err := template.New("").Funcs(funcs).ParseFS(files, "templates/*.html")
+	if err != nil {
+		return err
+	}
+	s := &site{title: title, pages: make(map[string]*Page), types: []*navType{}, templates: templates}
+
+	for idx := range project.EntityTypes {
+		s.addType(&project.EntityTypes[idx])
+	}
+	for _, et := range project.EntityTypes {
+		entities, err := project.ParseEntities(et.Name, sep)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, ent := range entities {
+			if ent.Header.Deleted {
+				continue
+			}
+			page, ok := s.pages[ent.Header.Id]
+			if !ok {
+				// entity files missing from project.xml are listed at the type root
+				page = s.addPage(s.navType(et.Name).Root, et.Name, ent.Header.Id, ent.Header.Name, []string{})
+			}
+			page.Entity = ent
+		}
+	}
+	s.link()
+
+	for _, nav := range s.types {
+		if err := os.MkdirAll(fmt.Sprintf("%s%s%s", out, sep, nav.Name), os.ModePerm); err != nil {
+			return err
+		}
+	}
+	for _, page := range s.pages {
+		if err := s.writePage(page, out, sep); err != nil {
+			return err
+		}
+	}
+	if err := s.writeIndex(out, sep); err != nil {
+		return err
+	}
+	if err := s.writeSearchIndex(out, sep); err != nil {
+		return err
+	}
+	for _, name := range []string{"style.css", "search.js"} {
+		data, err := files.ReadFile("assets/" + name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(fmt.Sprintf("%s%s%s", out, sep, name), data, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// navType returns the navigation tree of an entity type, which is added if it is missing.
+func (s *site) navType(name string) *navType {
+	for _, nav := range s.types {
+		if nav.Name == name {
+			return nav
+		}
+	}
+	nav := &navType{Name: name, Root: &navFolder{Name: name}}
+	s.types = append(s.types, nav)
+	return nav
+}
+
+// addType adds the pages of an entity type in project.xml order.
+func (s *site) addType(et *jbproj.EntityType) {
+	var addFolder func(parent *navFolder, folder jbproj.Folder, folders []string)
+	addFolder = func(parent *navFolder, folder jbproj.Folder, folders []string) {
+		nav := &navFolder{Name: folder.Name}
+		parent.Folders = append(parent.Folders, nav)
+		folders = append(append([]string{}, folders...), folder.Name)
+		for _, sub := range folder.Subfolders {
+			addFolder(nav, sub, folders)
+		}
+		for _, ent := range folder.Entities {
+			s.addPage(nav, et.Name, ent.Id, ent.Name, folders)
+		}
+	}
+
+	root := s.navType(et.Name).Root
+	for _, folder := range et.Folders {
+		addFolder(root, folder, []string{})
+	}
+	for _, ent := range et.Entities {
+		s.addPage(root, et.Name, ent.Id, ent.Name, []string{})
+	}
+}
+
+// addPage adds an entity page to a navigation folder.
+func (s *site) addPage(nav *navFolder, typeName string, id string, name string, folders []string) *Page {
+	page := &Page{
+		Id:      id,
+		Name:    name,
+		Type:    typeName,
+		Folders: folders,
+		URL:     fmt.Sprintf("%s/%s.html", typeName, id),
+	}
+	s.pages[id] = page
+	nav.Pages = append(nav.Pages, page)
+	s.navType(typeName).Count++
+	return page
+}
+
+// link finds the references of scripts and the activities of operations.
+func (s *site) link() {
+	for _, page := range s.pages {
+		if page.Entity == nil {
+			continue
+		}
+
+		ids := []string{}
+		switch page.Type {
+		case jbproj.SCRIPT:
+			ids = xref.ScriptReferences(page.Entity.KongaString)
+		case jbproj.OPERATION:
+			for _, act := range page.Entity.Pipeline.Activities.Activities {
+				ids = append(ids, act.ContentId)
+			}
+		}
+
+		for _, id := range ids {
+			target, ok := s.pages[id]
+			if !ok || containsPage(page.Calls, target) {
+				continue
+			}
+			page.Calls = append(page.Calls, target)
+			target.CalledBy = append(target.CalledBy, page)
+		}
+	}
+	for _, page := range s.pages {
+		sortPages(page.Calls)
+		sortPages(page.CalledBy)
+	}
+}
+
+// containsPage reports whether the page is in the list.
+func containsPage(pages []*Page, page *Page) bool {
+	for _, p := range pages {
+		if p == page {
+			return true
+		}
+	}
+	return false
+}
+
+// sortPages orders pages by type and path.
+func sortPages(pages []*Page) {
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Type+"/"+pages[i].Path() < pages[j].Type+"/"+pages[j].Path()
+	})
+}
+
+// href returns the link from an entity page to the page of an entity ID, empty if it is not documented.
+func (s *site) href(id string) string {
+	if page, ok := s.pages[id]; ok {
+		return "../" + page.URL
+	}
+	return ""
+}
+
+// writePage renders an entity page.
+func (s *site) writePage(page *Page, out string, sep string) error {
+	data := map[string]any{
+		"Title": s.title,
+		"Root":  "../",
+		"Page":  page,
+	}
+
+	if page.Entity != nil {
+		properties := []propertyView{}
+		for _, item := range page.Entity.Props.Items {
+			value, masked := secrets.MaskProperty(item.Key, item.Value, item.Enc)
+			properties = append(properties, propertyView{Key: item.Key, Value: value, Masked: masked})
+		}
+		data["Properties"] = properties
+
+		if page.Type == jbproj.OPERATION {
+			activities := []activityView{}
+			for _, act := range page.Entity.Pipeline.Activities.Activities {
+				activities = append(activities, activityView{Role: act.Role, Type: act.Type, Target: s.pages[act.ContentId], ContentId: act.ContentId})
+			}
+			data["OpType"] = page.Entity.Pipeline.OpType
+			data["Activities"] = activities
+		}
+
+		if page.Type == jbproj.SCRIPT {
+			if code, ok := xref.JavaScriptCode(page.Entity.KongaString); ok {
+				data["Language"] = "JavaScript"
+				data["Code"] = highlightJavaScript(code, s.href)
+			} else {
+				data["Language"] = "Jitterbit Script"
+				data["Code"] = highlightScript(page.Entity.KongaString, s.href)
+			}
+		}
+	}
+
+	return s.render("page.html", data, fmt.Sprintf("%s%s%s%s%s.html", out, sep, page.Type, sep, page.Id))
+}
+
+// writeIndex renders the index page with the navigation trees.
+func (s *site) writeIndex(out string, sep string) error {
+	data := map[string]any{
+		"Title": s.title,
+		"Root":  "",
+		"Types": s.types,
+	}
+	return s.render("index.html", data, fmt.Sprintf("%s%sindex.html", out, sep))
+}
+
+// render executes a template into a file.
+func (s *site) render(name string, data any, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := s.templates.ExecuteTemplate(file, name, data); err != nil {
+		return fmt.Errorf("[Generate] %s: %s", filePath, err.Error())
+	}
+	return nil
+}
+
+// writeSearchIndex writes the search entries as a script, which unlike JSON can be loaded from file:// URLs.
+func (s *site) writeSearchIndex(out string, sep string) error {
+	entries := []searchEntry{}
+	for _, nav := range s.types {
+		var addFolder func(folder *navFolder)
+		addFolder = func(folder *navFolder) {
+			for _, page := range folder.Pages {
+				entry := searchEntry{Name: page.Name, Type: page.Type, Path: page.Path(), URL: page.URL}
+				if page.Entity != nil && page.Type == jbproj.SCRIPT {
+					entry.Text = page.Entity.KongaString
+				}
+				entries = append(entries, entry)
+			}
+			for _, sub := range folder.Folders {
+				addFolder(sub)
+			}
+		}
+		addFolder(nav.Root)
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	content := fmt.Sprintf("var SEARCH_INDEX = %s;\n", data)
+	return os.WriteFile(fmt.Sprintf("%s%ssearch-index.js", out, sep), []byte(content), os.ModePerm)
+}
@@ -38,3 +38,13 @@ func ParseEntity(filePath string) (*Entity, error) {
 
 	return &entity, nil
 }
+
+// AttrValue returns the value of an attribute of a streamed element, empty if it is missing.
+func AttrValue(elem xml.StartElement, name string) string {
+	for _, attr := range elem.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,311 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	jbproj "jbextractor/jitterbit/project"
+
+	"golang.org/x/exp/slices"
+)
+
+const OPENAPI_VERSION = "3.0.3"
+
+// Setting keys of APIs and their routes, matched against properties, attributes and child elements.
+var (
+	apiVersionKey     = regexp.MustCompile(`(?i)^(api.?)?version$`)
+	apiRootKey        = regexp.MustCompile(`(?i)root|base.?path|base.?url`)
+	apiDescriptionKey = regexp.MustCompile(`(?i)description`)
+	apiMethodKey      = regexp.MustCompile(`(?i)^(http.?)?(method|verb)$`)
+	apiPathKey        = regexp.MustCompile(`(?i)^(path|resource|route|url|uri)(.?path)?$`)
+	apiOperationKey   = regexp.MustCompile(`(?i)operation`)
+	apiResponseKey    = regexp.MustCompile(`(?i)response.?type`)
+	apiContentKey     = regexp.MustCompile(`(?i)content.?type|mime|media.?type`)
+	// Path parameters, {id} or :id.
+	pathParam = regexp.MustCompile(`\{([^}/]+)\}|:(\w+)`)
+	// Characters not allowed in operation IDs.
+	operationIdChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// HTTP methods of OpenAPI path items.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Sidecar metadata of an exported API.
+type ApiMetadata struct {
+	Metadata
+	// OpenAPI document file name.
+	OpenApi string     `json:"openapi"`
+	Routes  []ApiRoute `json:"routes"`
+}
+
+// A route of an API handled by an operation.
+type ApiRoute struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Operation entity path.
+	Operation    string `json:"operation"`
+	ResponseType string `json:"responseType"`
+	ContentType  string `json:"contentType"`
+}
+
+// The top-level fields of an OpenAPI document, in the usual order.
+type openApiDocument struct {
+	OpenApi     string              `json:"openapi"`
+	Info        map[string]string   `json:"info"`
+	Servers     []map[string]string `json:"servers"`
+	Paths       map[string]any      `json:"paths"`
+	JitterbitId string              `json:"x-jitterbit-id"`
+}
+
+// An element of an entity file with its attributes and child element texts as settings.
+type apiNode struct {
+	settings map[string]string
+	children []*apiNode
+}
+
+// ApiEntities writes each API as an OpenAPI 3 document <name>.openapi.json with the routes, their methods, the
+// handling operation as x-jitterbit-operation and the response type, with a <name>.json sidecar listing the routes.
+func (e *Exporter) ApiEntities() error {
+	files, err := e.entities(jbproj.API_ENTITY)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		meta := ApiMetadata{Metadata: e.metadata(file), Routes: []ApiRoute{}}
+		settings := make(map[string]string)
+		for _, prop := range meta.Properties {
+			if !prop.Masked {
+				settings[prop.Key] = prop.Value
+			}
+		}
+		root := parseApiNodes(file.data)
+		for key, value := range root.settings {
+			if _, ok := settings[key]; !ok {
+				settings[key] = value
+			}
+		}
+
+		var addRoutes func(node *apiNode)
+		addRoutes = func(node *apiNode) {
+			for _, child := range node.children {
+				method := strings.ToLower(findSetting(child.settings, apiMethodKey))
+				path := findSetting(child.settings, apiPathKey)
+				if method == "" || path == "" {
+					addRoutes(child)
+					continue
+				}
+				route := ApiRoute{
+					Method:       method,
+					Path:         openApiPath(path),
+					ResponseType: findSetting(child.settings, apiResponseKey),
+					ContentType:  findSetting(child.settings, apiContentKey),
+				}
+				for _, key := range sortedKeys(child.settings) {
+					if apiOperationKey.MatchString(key) && e.isEntity(child.settings[key]) {
+						route.Operation = e.entityRef(child.settings[key])
+						break
+					}
+				}
+				meta.Routes = append(meta.Routes, route)
+			}
+		}
+		addRoutes(root)
+		sort.SliceStable(meta.Routes, func(i, j int) bool {
+			return meta.Routes[i].Path < meta.Routes[j].Path
+		})
+		if len(meta.Routes) == 0 {
+			e.warn(file, "No routes found")
+		}
+
+		doc := e.openApi(file, settings, meta.Routes)
+		meta.OpenApi = jbproj.SanitizeFileName(file.entity.Header.Name) + ".openapi.json"
+		if err := writeJSON(file.base+".openapi.json", doc); err != nil {
+			return err
+		}
+		if err := writeJSON(file.base+".json", meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openApi returns the OpenAPI document of an API, the server URL is the API root and version.
+func (e *Exporter) openApi(file *entityFile, settings map[string]string, routes []ApiRoute) *openApiDocument {
+	version := findSetting(settings, apiVersionKey)
+	info := map[string]string{"title": file.entity.Header.Name, "version": version}
+	if version == "" {
+		info["version"] = "1.0"
+	}
+	if description := findSetting(settings, apiDescriptionKey); description != "" {
+		info["description"] = description
+	}
+	doc := &openApiDocument{OpenApi: OPENAPI_VERSION, Info: info, Paths: make(map[string]any), JitterbitId: file.entity.Header.Id}
+
+	server := "/" + strings.Trim(findSetting(settings, apiRootKey), "/")
+	if version != "" {
+		server = strings.TrimSuffix(server, "/") + "/" + version
+	}
+	doc.Servers = []map[string]string{{"url": server}}
+
+	operationIds := make(map[string]bool)
+	for _, route := range routes {
+		if !slices.Contains(httpMethods, route.Method) {
+			e.warn(file, "Unsupported route %s", route)
+			continue
+		}
+		item, ok := doc.Paths[route.Path].(map[string]any)
+		if !ok {
+			item = make(map[string]any)
+			doc.Paths[route.Path] = item
+		}
+		if _, ok := item[route.Method]; ok {
+			e.warn(file, "Duplicate route %s", route)
+			continue
+		}
+		// routes differing in punctuation only share the same ID
+		base := strings.Trim(operationIdChars.ReplaceAllString(route.Method+"_"+route.Path, "_"), "_")
+		operationId := base
+		for idx := 2; operationIds[operationId]; idx++ {
+			operationId = fmt.Sprintf("%s_%d", base, idx)
+		}
+		operationIds[operationId] = true
+		item[route.Method] = openApiOperation(route, operationId)
+	}
+	return doc
+}
+
+// openApiOperation returns the operation object of a route with an operation ID unique in the document.
+func openApiOperation(route ApiRoute, operationId string) map[string]any {
+	op := map[string]any{"operationId": operationId}
+	if route.Operation != "" {
+		op["summary"] = route.Operation[strings.LastIndex(route.Operation, "/")+1:]
+		op["x-jitterbit-operation"] = route.Operation
+	}
+
+	params := []map[string]any{}
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		params = append(params, map[string]any{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]string{"type": "string"},
+		})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if route.ResponseType != "" {
+		op["x-jitterbit-response-type"] = route.ResponseType
+	}
+	if strings.HasPrefix(strings.ToLower(route.ResponseType), "no") {
+		op["responses"] = map[string]any{"204": map[string]string{"description": "No response"}}
+		return op
+	}
+	contentType := route.ContentType
+	if contentType == "" {
+		contentType = "*/*"
+	}
+	description := "Response"
+	if route.ResponseType != "" {
+		description = route.ResponseType
+	}
+	op["responses"] = map[string]any{
+		"200": map[string]any{
+			"description": description,
+			"content":     map[string]any{contentType: map[string]any{}},
+		},
+	}
+	return op
+}
+
+// openApiPath returns a route path with a leading slash and {name} path parameters.
+func openApiPath(path string) string {
+	path = pathParam.ReplaceAllStringFunc(path, func(param string) string {
+		return "{" + strings.Trim(param, "{}:") + "}"
+	})
+	return "/" + strings.TrimLeft(path, "/")
+}
+
+// findSetting returns the first non-empty setting with a matching key, in key order.
+func findSetting(settings map[string]string, key *regexp.Regexp) string {
+	for _, k := range sortedKeys(settings) {
+		if settings[k] != "" && key.MatchString(k) {
+			return settings[k]
+		}
+	}
+	return ""
+}
+
+// sortedKeys returns the sorted keys of settings.
+func sortedKeys(settings map[string]string) []string {
+	keys := []string{}
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseApiNodes returns the element tree below the entity root except the header and properties,
+// the root settings are the attributes of the root element.
+func parseApiNodes(data []byte) *apiNode {
+	root := &apiNode{settings: make(map[string]string)}
+	stack := []*apiNode{}
+	text := ""
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	skip := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skip > 0 || (len(stack) == 1 && (t.Name.Local == "Header" || t.Name.Local == "Properties")) {
+				skip++
+				continue
+			}
+			node := root
+			if len(stack) > 0 {
+				node = &apiNode{settings: make(map[string]string)}
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			for _, attr := range t.Attr {
+				node.settings[attr.Name.Local] = attr.Value
+			}
+			stack = append(stack, node)
+			text = ""
+		case xml.CharData:
+			text += string(t)
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			// leaf elements are settings of their parent
+			if len(stack) > 0 && len(node.children) == 0 && len(node.settings) == 0 {
+				parent := stack[len(stack)-1]
+				parent.children = parent.children[:len(parent.children)-1]
+				if _, ok := parent.settings[t.Name.Local]; !ok {
+					parent.settings[t.Name.Local] = strings.TrimSpace(text)
+				}
+			}
+			text = ""
+		}
+	}
+	return root
+}
+
+// String returns a description of a route, e.g. GET /customers/{id}.
+func (route ApiRoute) String() string {
+	return fmt.Sprintf("%s %s", strings.ToUpper(route.Method), route.Path)
+}
@@ -0,0 +1,129 @@
+package export
+
+import (
+	"os"
+	"regexp"
+
+	jbproj "jbextractor/jitterbit/project"
+)
+
+// Connector endpoint types and the activity types using them.
+var connectorTypes = []struct {
+	endpoint   string
+	activities []string
+}{
+	{jbproj.NETSUITE_ENDPOINT, []string{jbproj.NETSUITE_GET_LIST, jbproj.NETSUITE_QUERY, jbproj.NETSUITE_UPSERT}},
+	{jbproj.SAP_ENDPOINT, []string{jbproj.SAP_FUNCTION}},
+	{jbproj.MSCRM_ENDPOINT, []string{jbproj.MSCRM_QUERY, jbproj.MSCRM_UPSERT}},
+	{jbproj.QUICKBOOKS_ENDPOINT, []string{jbproj.QUICKBOOKS_CREATE, jbproj.QUICKBOOKS_QUERY}},
+}
+
+// Property keys of connector settings.
+var (
+	connectorEndpointKey = regexp.MustCompile(`(?i)endpoint|connector|connection`)
+	connectorObjectKey   = regexp.MustCompile(`(?i)object|record|entity|function|bapi|rfc|table`)
+	connectorCriteriaKey = regexp.MustCompile(`(?i)criteria|filter|query|where|condition|search|fetch.?xml`)
+	// Accounts or tenants of services without an endpoint URL property.
+	connectorAccountKey = regexp.MustCompile(`(?i)account|tenant|organi[sz]ation|company|realm`)
+)
+
+// Sidecar metadata of an exported connector activity.
+type ConnectorMetadata struct {
+	Metadata
+	// Endpoint entity path.
+	Endpoint string `json:"endpoint"`
+	// Endpoint URL, host or account with credentials removed.
+	Address string `json:"address"`
+	// Record type, object or function name.
+	Object   string `json:"object"`
+	Criteria string `json:"criteria"`
+	// Summary file name.
+	Summary string   `json:"summary"`
+	Fields  []string `json:"fields"`
+}
+
+// Connectors writes the NetSuite, SAP, Microsoft Dynamics CRM and QuickBooks activities as <name>.md summaries of
+// the object or function, query criteria, other settings and field list, with a <name>.json sidecar.
+// Endpoint entities only get a sidecar, secrets masked; their address is added to the inventory for each activity.
+func (e *Exporter) Connectors() error {
+	for _, connector := range connectorTypes {
+		addresses := make(map[string]string)
+		endpoints, err := e.entities(connector.endpoint)
+		if err != nil {
+			return err
+		}
+		for _, file := range endpoints {
+			meta := e.metadata(file)
+			addresses[file.entity.Header.Id] = connectorAddress(meta.Properties)
+			if err := writeJSON(file.base+".json", meta); err != nil {
+				return err
+			}
+		}
+
+		for _, typeName := range connector.activities {
+			files, err := e.entities(typeName)
+			if err != nil {
+				return err
+			}
+			for _, file := range files {
+				if err := e.writeConnectorActivity(file, addresses); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeConnectorActivity writes the summary and sidecar of a connector activity and records its endpoint.
+func (e *Exporter) writeConnectorActivity(file *entityFile, addresses map[string]string) error {
+	meta := ConnectorMetadata{Metadata: e.metadata(file), Fields: fieldNames(file.data)}
+	settings := []Property{}
+	for _, prop := range meta.Properties {
+		switch {
+		case !prop.Masked && meta.Endpoint == "" && connectorEndpointKey.MatchString(prop.Key) && e.isEntity(prop.Value):
+			meta.Endpoint = e.entityRef(prop.Value)
+			meta.Address = redactURL(addresses[prop.Value])
+		case !prop.Masked && meta.Criteria == "" && prop.Value != "" && connectorCriteriaKey.MatchString(prop.Key):
+			meta.Criteria = prop.Value
+		case !prop.Masked && meta.Object == "" && prop.Value != "" && connectorObjectKey.MatchString(prop.Key):
+			meta.Object = prop.Value
+		default:
+			settings = append(settings, prop)
+		}
+	}
+	if meta.Endpoint == "" {
+		e.warn(file, "No endpoint found")
+	}
+
+	md := newSummary(file.entity.Header.Name, [][2]string{
+		{"Type", file.entity.Type},
+		{"Object", meta.Object},
+		{"Endpoint", meta.Endpoint},
+		{"Address", meta.Address},
+		{"Folder", meta.Folder},
+	})
+	if meta.Criteria != "" {
+		md.code("Criteria", meta.Criteria)
+	}
+	md.properties("Settings", settings, "None.")
+	md.list("Fields", meta.Fields, "No field definitions.")
+
+	meta.Summary = jbproj.SanitizeFileName(file.entity.Header.Name) + ".md"
+	if err := os.WriteFile(file.base+".md", []byte(md.String()), os.ModePerm); err != nil {
+		return err
+	}
+	if err := writeJSON(file.base+".json", meta); err != nil {
+		return err
+	}
+	e.addEndpoint(file, meta.Object, meta.Address)
+	return nil
+}
+
+// connectorAddress returns the endpoint address of a connector endpoint, else its account or tenant.
+func connectorAddress(props []Property) string {
+	if address := propertyEndpoint(props); address != "" {
+		return address
+	}
+	return findProperty(props, connectorAccountKey)
+}
@@ -0,0 +1,524 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"jbextractor/jitterbit/entity"
+	jbproj "jbextractor/jitterbit/project"
+)
+
+const (
+	XSD_NAMESPACE      = "http://www.w3.org/2001/XMLSchema"
+	JSON_SCHEMA_DRAFT  = "http://json-schema.org/draft-07/schema#"
+	DOCUMENT_USAGE_CSV = "_usage.csv"
+)
+
+// Document structure kinds.
+const (
+	DOC_FLAT string = "flat"
+	DOC_XML  string = "xml"
+	DOC_JSON string = "json"
+)
+
+// Property keys and values identifying the document kind.
+var (
+	docKindKey  = regexp.MustCompile(`(?i)type|format|kind`)
+	docKindFlat = regexp.MustCompile(`(?i)flat|csv|delimit|fixed|text`)
+)
+
+// Sidecar metadata of an exported document.
+type DocumentMetadata struct {
+	Metadata
+	// Structure kind: flat, xml or json, empty if unknown.
+	Kind string `json:"kind"`
+	// Schema file name, empty if the entity contains no structure.
+	Schema string `json:"schema"`
+	// Transformations using the document.
+	Transformations []DocumentUsage `json:"transformations"`
+}
+
+// A transformation using a document.
+type DocumentUsage struct {
+	Transformation string `json:"transformation"`
+	// Side of the mapping: source, target or empty if unknown.
+	Role string `json:"role"`
+}
+
+// A document field or segment.
+type field struct {
+	name      string
+	dataType  string
+	length    string
+	required  bool
+	repeating bool
+	children  []*field
+}
+
+// Documents writes each document as the closest standard schema: an embedded or generated XSD for XML,
+// a JSON Schema for JSON and a CSV field list for flat files, with a <name>.json metadata sidecar listing the
+// transformations using it. Document/_usage.csv summarizes the usage of all documents.
+func (e *Exporter) Documents() error {
+	files, err := e.entities(jbproj.DOCUMENT)
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	ids := make(map[string]bool)
+	for _, file := range files {
+		ids[file.entity.Header.Id] = true
+	}
+	usage, err := e.documentUsage(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		meta := DocumentMetadata{Metadata: e.metadata(file), Kind: documentKind(file), Transformations: usage[file.entity.Header.Id]}
+		if meta.Transformations == nil {
+			meta.Transformations = []DocumentUsage{}
+		}
+
+		ext, schema, err := documentSchema(file, &meta)
+		if err != nil {
+			return err
+		}
+		if ext == "" {
+			e.warn(file, "No document structure found")
+		} else {
+			meta.Schema = jbproj.SanitizeFileName(file.entity.Header.Name) + ext
+			if err := os.WriteFile(file.base+ext, schema, os.ModePerm); err != nil {
+				return err
+			}
+		}
+		if err := writeJSON(file.base+".json", meta); err != nil {
+			return err
+		}
+	}
+
+	return e.writeDocumentUsage(files, usage)
+}
+
+// documentSchema returns the schema file extension and content, the extension is empty if no structure was found.
+func documentSchema(file *entityFile, meta *DocumentMetadata) (string, []byte, error) {
+	if xsd, ok := findSchema(file.data); ok {
+		meta.Kind = DOC_XML
+		return ".xsd", []byte(xsd), nil
+	}
+	if schema, ok := findJSON(file.data); ok {
+		meta.Kind = DOC_JSON
+		return ".schema.json", schema, nil
+	}
+
+	fields := parseFields(file.data)
+	if len(fields) == 0 {
+		return "", nil, nil
+	}
+	switch meta.Kind {
+	case DOC_XML:
+		return ".xsd", generateXSD(fields), nil
+	case DOC_JSON:
+		data, err := json.MarshalIndent(generateJSONSchema(fields), "", "  ")
+		return ".schema.json", append(data, '\n'), err
+	}
+	data, err := fieldList(fields)
+	return ".csv", data, err
+}
+
+// documentKind returns the structure kind from the document properties, empty if unknown.
+func documentKind(file *entityFile) string {
+	for _, item := range file.entity.Props.Items {
+		if !docKindKey.MatchString(item.Key) {
+			continue
+		}
+		value := strings.ToLower(item.Value)
+		switch {
+		case strings.Contains(value, "json"):
+			return DOC_JSON
+		case strings.Contains(value, "xml"):
+			return DOC_XML
+		case docKindFlat.MatchString(value):
+			return DOC_FLAT
+		}
+	}
+	return ""
+}
+
+// findSchema returns the first XSD of an entity file.
+func findSchema(data []byte) (string, bool) {
+	return findEmbedded(data, XSD_NAMESPACE, "schema")
+}
+
+// findJSON returns the first JSON object stored as text in an entity file as a JSON Schema,
+// JSON samples are converted to a schema of their structure.
+func findJSON(data []byte) ([]byte, bool) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, false
+		}
+
+		texts := []string{}
+		switch t := token.(type) {
+		case xml.StartElement:
+			for _, attr := range t.Attr {
+				texts = append(texts, attr.Value)
+			}
+		case xml.CharData:
+			texts = append(texts, string(t))
+		}
+		for _, text := range texts {
+			text = strings.TrimSpace(text)
+			if !strings.HasPrefix(text, "{") {
+				continue
+			}
+			var value any
+			if json.Unmarshal([]byte(text), &value) != nil {
+				continue
+			}
+			object := value.(map[string]any)
+			if _, ok := object["$schema"]; !ok {
+				object = inferSchema(value)
+				object["$schema"] = JSON_SCHEMA_DRAFT
+			}
+			result, err := json.MarshalIndent(object, "", "  ")
+			if err != nil {
+				return nil, false
+			}
+			return append(result, '\n'), true
+		}
+	}
+}
+
+// inferSchema returns the JSON Schema of a decoded JSON sample.
+func inferSchema(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		properties := make(map[string]any)
+		for key, child := range v {
+			properties[key] = inferSchema(child)
+		}
+		return map[string]any{"type": "object", "properties": properties}
+	case []any:
+		schema := map[string]any{"type": "array"}
+		if len(v) > 0 {
+			schema["items"] = inferSchema(v[0])
+		}
+		return schema
+	case string:
+		return map[string]any{"type": "string"}
+	case float64:
+		if v == float64(int64(v)) {
+			return map[string]any{"type": "integer"}
+		}
+		return map[string]any{"type": "number"}
+	case bool:
+		return map[string]any{"type": "boolean"}
+	}
+	return map[string]any{"type": "null"}
+}
+
+// parseFields returns the tree of named elements below the entity root except the header and properties.
+func parseFields(data []byte) []*field {
+	root := &field{}
+	// enclosing fields, nil for unnamed elements
+	stack := []*field{}
+	parent := func() *field {
+		for idx := len(stack) - 1; idx >= 0; idx-- {
+			if stack[idx] != nil {
+				return stack[idx]
+			}
+		}
+		return root
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	skip := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skip > 0 || (len(stack) == 1 && (t.Name.Local == "Header" || t.Name.Local == "Properties")) {
+				skip++
+				continue
+			}
+			f := newField(t)
+			if f != nil {
+				p := parent()
+				p.children = append(p.children, f)
+			}
+			stack = append(stack, f)
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return root.children
+}
+
+// newField returns the field described by an element with a name attribute, nil for other elements.
+func newField(elem xml.StartElement) *field {
+	attrs := make(map[string]string)
+	for _, attr := range elem.Attr {
+		attrs[strings.ToLower(attr.Name.Local)] = attr.Value
+	}
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if value, ok := attrs[key]; ok {
+				return value
+			}
+		}
+		return ""
+	}
+
+	name := first("name", "fieldname", "elementname")
+	if name == "" {
+		return nil
+	}
+	f := &field{
+		name:     name,
+		dataType: first("datatype", "type", "fieldtype"),
+		length:   first("length", "size", "width", "maxlength"),
+	}
+	if required, err := strconv.ParseBool(first("required", "mandatory")); err == nil {
+		f.required = required
+	}
+	if min, err := strconv.Atoi(first("minoccurs")); err == nil {
+		f.required = min > 0
+	}
+	if repeating, err := strconv.ParseBool(first("repeating", "repeat", "multiple")); err == nil {
+		f.repeating = repeating
+	}
+	if max := first("maxoccurs"); max == "unbounded" {
+		f.repeating = true
+	} else if max, err := strconv.Atoi(max); err == nil {
+		f.repeating = max > 1
+	}
+	return f
+}
+
+// xsdType maps a field data type to an XSD built-in type.
+func xsdType(dataType string) string {
+	switch strings.ToLower(dataType) {
+	case "int", "integer", "long", "short":
+		return "xs:integer"
+	case "decimal", "double", "float", "number", "numeric":
+		return "xs:decimal"
+	case "bool", "boolean":
+		return "xs:boolean"
+	case "date":
+		return "xs:date"
+	case "datetime", "timestamp":
+		return "xs:dateTime"
+	}
+	return "xs:string"
+}
+
+// generateXSD returns an XSD with an element per field, fields with children are complex types.
+func generateXSD(fields []*field) []byte {
+	var xsd strings.Builder
+	xsd.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(&xsd, "<xs:schema xmlns:xs=\"%s\" elementFormDefault=\"qualified\">\n", XSD_NAMESPACE)
+	var writeElement func(f *field, indent string, nested bool)
+	writeElement = func(f *field, indent string, nested bool) {
+		occurs := ""
+		if nested && !f.required {
+			occurs += " minOccurs=\"0\""
+		}
+		if nested && f.repeating {
+			occurs += " maxOccurs=\"unbounded\""
+		}
+		if len(f.children) == 0 {
+			fmt.Fprintf(&xsd, "%s<xs:element name=\"%s\" type=\"%s\"%s/>\n", indent, xmlAttr(f.name), xsdType(f.dataType), occurs)
+			return
+		}
+		fmt.Fprintf(&xsd, "%s<xs:element name=\"%s\"%s>\n", indent, xmlAttr(f.name), occurs)
+		fmt.Fprintf(&xsd, "%s  <xs:complexType>\n%s    <xs:sequence>\n", indent, indent)
+		for _, child := range f.children {
+			writeElement(child, indent+"      ", true)
+		}
+		fmt.Fprintf(&xsd, "%s    </xs:sequence>\n%s  </xs:complexType>\n", indent, indent)
+		fmt.Fprintf(&xsd, "%s</xs:element>\n", indent)
+	}
+	for _, f := range fields {
+		writeElement(f, "  ", false)
+	}
+	xsd.WriteString("</xs:schema>\n")
+	return []byte(xsd.String())
+}
+
+// xmlAttr escapes an attribute value.
+func xmlAttr(value string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(value))
+	return escaped.String()
+}
+
+// jsonType maps a field data type to a JSON Schema type.
+func jsonType(dataType string) string {
+	switch xsdType(dataType) {
+	case "xs:integer":
+		return "integer"
+	case "xs:decimal":
+		return "number"
+	case "xs:boolean":
+		return "boolean"
+	}
+	return "string"
+}
+
+// generateJSONSchema returns a JSON Schema of an object with a property per field.
+func generateJSONSchema(fields []*field) map[string]any {
+	var object func(fields []*field) map[string]any
+	object = func(fields []*field) map[string]any {
+		properties := make(map[string]any)
+		required := []string{}
+		for _, f := range fields {
+			schema := map[string]any{"type": jsonType(f.dataType)}
+			if len(f.children) > 0 {
+				schema = object(f.children)
+			}
+			if f.repeating {
+				schema = map[string]any{"type": "array", "items": schema}
+			}
+			properties[f.name] = schema
+			if f.required {
+				required = append(required, f.name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+
+	schema := object(fields)
+	schema["$schema"] = JSON_SCHEMA_DRAFT
+	return schema
+}
+
+// fieldList returns a CSV list of the segments and fields with their segment paths in document order.
+func fieldList(fields []*field) ([]byte, error) {
+	var data bytes.Buffer
+	writer := csv.NewWriter(&data)
+	writer.Write([]string{"segment", "field", "type", "length", "required", "repeating"})
+	var write func(fields []*field, segment string)
+	write = func(fields []*field, segment string) {
+		for _, f := range fields {
+			if len(f.children) > 0 {
+				path := f.name
+				if segment != "" {
+					path = segment + "/" + f.name
+				}
+				// segment rows have no field name
+				writer.Write([]string{path, "", f.dataType, f.length, strconv.FormatBool(f.required), strconv.FormatBool(f.repeating)})
+				write(f.children, path)
+				continue
+			}
+			writer.Write([]string{segment, f.name, f.dataType, f.length, strconv.FormatBool(f.required), strconv.FormatBool(f.repeating)})
+		}
+	}
+	write(fields, "")
+	writer.Flush()
+	return data.Bytes(), writer.Error()
+}
+
+// documentUsage returns the transformations referencing each of the document IDs, the role is taken from the name
+// of the referencing attribute, element or property key.
+func (e *Exporter) documentUsage(ids map[string]bool) (map[string][]DocumentUsage, error) {
+	usage := make(map[string][]DocumentUsage)
+	files, err := e.readEntities(jbproj.TRANSFORMATION)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		path := file.entityPath()
+		add := func(id string, context string) {
+			if !ids[id] {
+				return
+			}
+			role := ""
+			switch context = strings.ToLower(context); {
+			case strings.Contains(context, "source"):
+				role = "source"
+			case strings.Contains(context, "target"):
+				role = "target"
+			}
+			for idx, u := range usage[id] {
+				if u.Transformation == path {
+					if u.Role == "" {
+						usage[id][idx].Role = role
+					}
+					return
+				}
+			}
+			usage[id] = append(usage[id], DocumentUsage{Transformation: path, Role: role})
+		}
+
+		decoder := xml.NewDecoder(bytes.NewReader(file.data))
+		element := ""
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				// property items name the reference in their key
+				element = t.Name.Local + " " + entity.AttrValue(t, "key")
+				for _, attr := range t.Attr {
+					add(strings.TrimSpace(attr.Value), element+" "+attr.Name.Local)
+				}
+			case xml.CharData:
+				add(strings.TrimSpace(string(t)), element)
+			}
+		}
+	}
+
+	for _, usages := range usage {
+		sort.Slice(usages, func(i, j int) bool { return usages[i].Transformation < usages[j].Transformation })
+	}
+	return usage, nil
+}
+
+// writeDocumentUsage writes Document/_usage.csv with a row per document and transformation, unused documents have an empty transformation.
+func (e *Exporter) writeDocumentUsage(files []*entityFile, usage map[string][]DocumentUsage) error {
+	rows := [][]string{}
+	for _, file := range files {
+		path := file.entityPath()
+		usages := usage[file.entity.Header.Id]
+		if len(usages) == 0 {
+			rows = append(rows, []string{path, file.entity.Header.Id, "", ""})
+		}
+		for _, u := range usages {
+			rows = append(rows, []string{path, file.entity.Header.Id, u.Transformation, u.Role})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+	file, err := os.Create(fmt.Sprintf("%s%s%s%s%s", e.out, e.sep, jbproj.DOCUMENT, e.sep, DOCUMENT_USAGE_CSV))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"document", "id", "transformation", "role"})
+	writer.WriteAll(rows)
+	return writer.Error()
+}
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	jbproj "jbextractor/jitterbit/project"
+)
+
+// Property keys of email message fields, in header order.
+var emailFields = []struct {
+	header string
+	key    *regexp.Regexp
+}{
+	{"From", regexp.MustCompile(`(?i)^(from|sender)`)},
+	{"Reply-To", regexp.MustCompile(`(?i)^reply`)},
+	{"To", regexp.MustCompile(`^(?:[Tt]o|[Rr]ecipients?)(?:$|[A-Z_\s-])`)},
+	{"Cc", regexp.MustCompile(`(?i)^cc`)},
+	{"Bcc", regexp.MustCompile(`(?i)^bcc`)},
+	{"Subject", regexp.MustCompile(`(?i)subject`)},
+}
+
+var (
+	emailBodyKey = regexp.MustCompile(`(?i)body|message|text|content`)
+	// Placeholders of global variables, e.g. [$order.id], and of project variables without $.
+	placeholder = regexp.MustCompile(`\[(\$?[A-Za-z_][\w.]*)\]`)
+)
+
+// Sidecar metadata of an exported email message.
+type EmailMetadata struct {
+	Metadata
+	// Message file name.
+	Message string `json:"message"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Cc      string `json:"cc"`
+	Bcc     string `json:"bcc"`
+	Subject string `json:"subject"`
+	// Global and project variables referenced by [$name] placeholders, sorted.
+	Globals []string `json:"globals"`
+}
+
+// EmailMessages writes each email message as an .eml-like <name>.eml file with headers and body and a <name>.json
+// sidecar listing the variables referenced by placeholders in any field. SMTP servers are added to the endpoint inventory.
+func (e *Exporter) EmailMessages() error {
+	files, err := e.entities(jbproj.EMAIL)
+	if err != nil {
+		return err
+	}
+	projectVars := e.projectVariables()
+
+	for _, file := range files {
+		meta := EmailMetadata{Metadata: e.metadata(file)}
+		headers := make(map[string]string)
+		body := ""
+		for _, prop := range meta.Properties {
+			if prop.Masked || prop.Value == "" {
+				continue
+			}
+			matched := false
+			for _, f := range emailFields {
+				if _, ok := headers[f.header]; !ok && f.key.MatchString(prop.Key) {
+					headers[f.header] = prop.Value
+					matched = true
+					break
+				}
+			}
+			if !matched && body == "" && emailBodyKey.MatchString(prop.Key) {
+				body = prop.Value
+			}
+		}
+		if body == "" {
+			body = file.entity.KongaString
+		}
+		meta.From, meta.To, meta.Cc, meta.Bcc, meta.Subject = headers["From"], headers["To"], headers["Cc"], headers["Bcc"], headers["Subject"]
+
+		globals := make(map[string]bool)
+		for _, text := range append([]string{body}, mapValues(headers)...) {
+			for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+				name := match[1]
+				if strings.HasPrefix(name, "$") || projectVars[name] {
+					globals[strings.TrimPrefix(name, "$")] = true
+				}
+			}
+		}
+		meta.Globals = []string{}
+		for name := range globals {
+			meta.Globals = append(meta.Globals, name)
+		}
+		sort.Strings(meta.Globals)
+
+		var eml strings.Builder
+		for _, f := range emailFields {
+			if value, ok := headers[f.header]; ok {
+				fmt.Fprintf(&eml, "%s: %s\n", f.header, strings.Join(strings.Fields(value), " "))
+			}
+		}
+		contentType := "text/plain"
+		if strings.Contains(strings.ToLower(body), "<html") || strings.Contains(strings.ToLower(body), "<body") {
+			contentType = "text/html"
+		}
+		fmt.Fprintf(&eml, "X-Jitterbit-Id: %s\n", file.entity.Header.Id)
+		fmt.Fprintf(&eml, "Content-Type: %s; charset=utf-8\n\n%s", contentType, body)
+		if !strings.HasSuffix(body, "\n") {
+			eml.WriteString("\n")
+		}
+
+		meta.Message = jbproj.SanitizeFileName(file.entity.Header.Name) + ".eml"
+		if err := os.WriteFile(file.base+".eml", []byte(eml.String()), os.ModePerm); err != nil {
+			return err
+		}
+		if err := writeJSON(file.base+".json", meta); err != nil {
+			return err
+		}
+		// SMTP server
+		e.addEndpoint(file, "", propertyEndpoint(meta.Properties))
+	}
+	return nil
+}
+
+// projectVariables returns the names of the project variables.
+func (e *Exporter) projectVariables() map[string]bool {
+	names := make(map[string]bool)
+	for _, et := range e.project.EntityTypes {
+		if et.Name != jbproj.VARIABLE {
+			continue
+		}
+		var addFolders func(folders []jbproj.Folder)
+		addFolders = func(folders []jbproj.Folder) {
+			for _, folder := range folders {
+				for _, ent := range folder.Entities {
+					names[ent.Name] = true
+				}
+				addFolders(folder.Subfolders)
+			}
+		}
+		for _, ent := range et.Entities {
+			names[ent.Name] = true
+		}
+		addFolders(et.Folders)
+	}
+	return names
+}
+
+// mapValues returns the values of a map.
+func mapValues(m map[string]string) []string {
+	values := []string{}
+	for _, value := range m {
+		values = append(values, value)
+	}
+	return values
+}
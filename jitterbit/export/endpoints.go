@@ -0,0 +1,148 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/secrets"
+)
+
+// Endpoint inventory file in the extracted project root.
+const ENDPOINTS_CSV = "endpoints.csv"
+
+// Property keys of endpoint addresses.
+var endpointKey = regexp.MustCompile(`(?i)endpoint|url|uri|address|location|host|server`)
+
+// location attributes of XML elements with their quoted value.
+var locationAttr = regexp.MustCompile(`(\slocation\s*=\s*)("[^"]*"|'[^']*')`)
+
+// An external system address used by an entity.
+type Endpoint struct {
+	// Entity type, e.g. WebServiceCall.
+	Type string
+	// Slash-separated folder path including the entity name.
+	Entity string
+	// Called operation or action, e.g. the WSDL operation.
+	Operation string
+	// Endpoint address with credentials removed.
+	URL string
+}
+
+// addEndpoint records an endpoint for the inventory, empty addresses are skipped.
+func (e *Exporter) addEndpoint(file *entityFile, operation string, address string) {
+	if address == "" {
+		return
+	}
+	e.endpoints = append(e.endpoints, &Endpoint{
+		Type:      file.entity.Type,
+		Entity:    file.entityPath(),
+		Operation: operation,
+		URL:       redactURL(address),
+	})
+}
+
+// propertyEndpoint returns the first endpoint address among the masked properties of an entity.
+func propertyEndpoint(props []Property) string {
+	for _, prop := range props {
+		if !prop.Masked && prop.Value != "" && endpointKey.MatchString(prop.Key) {
+			return prop.Value
+		}
+	}
+	return ""
+}
+
+// entityEndpoint returns the first endpoint address of an entity file, including URLs masked for their password
+// which are returned with the password removed.
+func entityEndpoint(file *entityFile) string {
+	for _, item := range file.entity.Props.Items {
+		if item.Enc || item.Value == "" || !endpointKey.MatchString(item.Key) {
+			continue
+		}
+		if address := redactURL(item.Value); !secrets.IsSecretProperty(item.Key, address) {
+			return address
+		}
+	}
+	return ""
+}
+
+// redactURL removes the password of URLs with user info and masks query parameters holding credentials,
+// e.g. api_key, other addresses are returned unchanged.
+func redactURL(address string) string {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return address
+	}
+
+	masked := false
+	params := strings.Split(parsed.RawQuery, "&")
+	for idx, param := range params {
+		key, value, found := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(key)
+		if found && err == nil && secrets.IsSensitiveKey(name) && value != "****" {
+			params[idx] = key + "=****"
+			masked = true
+		}
+	}
+	if parsed.User == nil && !masked {
+		return address
+	}
+	parsed.RawQuery = strings.Join(params, "&")
+	if parsed.User == nil {
+		return parsed.String()
+	}
+
+	_, hasPassword := parsed.User.Password()
+	parsed.User = url.User(parsed.User.Username())
+	if !hasPassword {
+		return parsed.String()
+	}
+	// the escaped user name contains no @
+	return strings.Replace(parsed.String(), "@", ":****@", 1)
+}
+
+// redactLocations applies redactURL to the location attributes of an XML document, e.g. the SOAP addresses of a WSDL.
+func redactLocations(doc string) string {
+	escaper := strings.NewReplacer("&", "&amp;", "<", "&lt;", "\"", "&quot;", "'", "&apos;")
+	return locationAttr.ReplaceAllStringFunc(doc, func(attr string) string {
+		loc := locationAttr.FindStringSubmatchIndex(attr)
+		quoted := attr[loc[4]:loc[5]]
+		value := html.UnescapeString(quoted[1 : len(quoted)-1])
+		redacted := redactURL(value)
+		if redacted == value {
+			return attr
+		}
+		return attr[:loc[4]] + quoted[:1] + escaper.Replace(redacted) + quoted[:1]
+	})
+}
+
+// Endpoints writes the inventory of the endpoints found by the previous exports to endpoints.csv in the extracted project root.
+func (e *Exporter) Endpoints() error {
+	if len(e.endpoints) == 0 {
+		return nil
+	}
+	sort.SliceStable(e.endpoints, func(i, j int) bool {
+		if e.endpoints[i].Type != e.endpoints[j].Type {
+			return e.endpoints[i].Type < e.endpoints[j].Type
+		}
+		return e.endpoints[i].Entity < e.endpoints[j].Entity
+	})
+
+	file, err := os.Create(fmt.Sprintf("%s%s%s", e.out, e.sep, ENDPOINTS_CSV))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"type", "entity", "operation", "url"})
+	for _, endpoint := range e.endpoints {
+		writer.Write([]string{endpoint.Type, endpoint.Entity, endpoint.Operation, endpoint.URL})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,264 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/entity"
+	jbproj "jbextractor/jitterbit/project"
+	"jbextractor/jitterbit/secrets"
+
+	"golang.org/x/exp/slices"
+)
+
+// Writes entity types without a dedicated extraction into the folder hierarchy of the extracted project.
+type Exporter struct {
+	project *jbproj.Project
+	// Extracted project path.
+	out string
+	sep string
+	// Entities which could only be exported partially.
+	Warnings []string
+	// Endpoints of the exported entities for the inventory.
+	endpoints []*Endpoint
+}
+
+// NewExporter returns an exporter writing to the extracted project path.
+func NewExporter(project *jbproj.Project, out string, sep string) *Exporter {
+	return &Exporter{project: project, out: out, sep: sep, Warnings: []string{}}
+}
+
+// Sidecar metadata of an exported entity.
+type Metadata struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Folder string `json:"folder"`
+	// Entity file relative to the environment, e.g. Data/XsltTransform/<id>.xml.
+	Source     string     `json:"source"`
+	Properties []Property `json:"properties"`
+}
+
+// An entity property with encrypted and secret values masked.
+type Property struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Masked bool   `json:"masked,omitempty"`
+}
+
+// An entity file of the environment.
+type entityFile struct {
+	path   string
+	data   []byte
+	entity *entity.Entity
+	// Parent folder names.
+	folders []string
+	// Export path without extension, e.g. <out>/XsltTransform/Folder/Name.
+	base string
+}
+
+// entityPath returns the slash-separated folder path including the entity name.
+func (file *entityFile) entityPath() string {
+	return strings.Join(append(append([]string{}, file.folders...), file.entity.Header.Name), "/")
+}
+
+// entities reads the entity files of a type and creates their export folders, empty if the project has no such entities.
+func (e *Exporter) entities(typeName string) ([]*entityFile, error) {
+	files, err := e.readEntities(typeName)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file.base), os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// readEntities reads the entity files of a type, empty if the project has no such entities.
+func (e *Exporter) readEntities(typeName string) ([]*entityFile, error) {
+	paths, err := e.project.EntityFiles(typeName, e.sep)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := []*entityFile{}
+	for _, path := range paths {
+		ent, err := entity.ParseEntity(path)
+		if err != nil {
+			return nil, err
+		}
+		if ent.Header.Deleted {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		_, found, folders := e.project.FindEntity(ent.Header.Id)
+		if found == nil {
+			return nil, fmt.Errorf("[Export] Corrupted project.xml - %s %s was not found", typeName, ent.Header.Id)
+		}
+		dir := fmt.Sprintf("%s%s%s", e.out, e.sep, typeName)
+		for _, folder := range folders {
+			dir = fmt.Sprintf("%s%s%s", dir, e.sep, jbproj.SanitizeFileName(folder))
+		}
+
+		files = append(files, &entityFile{
+			path:    path,
+			data:    data,
+			entity:  ent,
+			folders: folders,
+			base:    fmt.Sprintf("%s%s%s", dir, e.sep, jbproj.SanitizeFileName(ent.Header.Name)),
+		})
+	}
+	return files, nil
+}
+
+// metadata returns the common sidecar metadata of an entity file.
+func (e *Exporter) metadata(file *entityFile) Metadata {
+	meta := Metadata{
+		Id:         file.entity.Header.Id,
+		Name:       file.entity.Header.Name,
+		Type:       file.entity.Type,
+		Folder:     strings.Join(file.folders, "/"),
+		Properties: []Property{},
+	}
+	if rel, err := filepath.Rel(e.project.EnvPath, file.path); err == nil {
+		meta.Source = filepath.ToSlash(rel)
+	}
+	for _, item := range file.entity.Props.Items {
+		value, masked := secrets.MaskProperty(item.Key, item.Value, item.Enc)
+		meta.Properties = append(meta.Properties, Property{Key: item.Key, Value: value, Masked: masked})
+	}
+	return meta
+}
+
+// warn records an entity which could only be exported partially.
+func (e *Exporter) warn(file *entityFile, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	e.Warnings = append(e.Warnings, fmt.Sprintf("[Export] %s %s: %s", file.entity.Type, file.entity.Header.Name, msg))
+}
+
+// writeJSON writes an indented JSON file.
+func writeJSON(filePath string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, append(data, '\n'), os.ModePerm)
+}
+
+// findEmbedded returns the first XML document with one of the root elements in the namespace from an entity file,
+// either embedded as XML elements or stored as escaped text in an element or attribute.
+func findEmbedded(data []byte, namespace string, roots ...string) (string, bool) {
+	isRoot := func(text string) bool {
+		if !strings.Contains(text, namespace) {
+			return false
+		}
+		for _, root := range roots {
+			if strings.Contains(text, ":"+root) || strings.Contains(text, "<"+root) {
+				return true
+			}
+		}
+		return false
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// namespace declarations of the enclosing elements by prefix, empty for the default namespace
+	scopes := []map[string]string{}
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			// io.EOF or a malformed file
+			return "", false
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space == namespace && slices.Contains(roots, t.Name.Local) {
+				if err := decoder.Skip(); err != nil && err != io.EOF {
+					return "", false
+				}
+				return declareNamespaces(string(data[start:decoder.InputOffset()]), t, scopes) + "\n", true
+			}
+			scopes = append(scopes, namespaceDeclarations(t))
+			for _, attr := range t.Attr {
+				if isRoot(attr.Value) {
+					return strings.TrimSpace(attr.Value) + "\n", true
+				}
+			}
+		case xml.EndElement:
+			scopes = scopes[:len(scopes)-1]
+		case xml.CharData:
+			if isRoot(string(t)) {
+				return strings.TrimSpace(string(t)) + "\n", true
+			}
+		}
+	}
+}
+
+// namespaceDeclarations returns the xmlns attributes of an element by prefix, empty for the default namespace.
+func namespaceDeclarations(elem xml.StartElement) map[string]string {
+	decls := make(map[string]string)
+	for _, attr := range elem.Attr {
+		switch {
+		case attr.Name.Space == "xmlns":
+			decls[attr.Name.Local] = attr.Value
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			decls[""] = attr.Value
+		}
+	}
+	return decls
+}
+
+// declareNamespaces adds the namespace declarations inherited from the enclosing elements to the start tag
+// of an extracted element, unless the element redeclares them.
+func declareNamespaces(raw string, elem xml.StartElement, scopes []map[string]string) string {
+	inherited := make(map[string]string)
+	for _, scope := range scopes {
+		for prefix, uri := range scope {
+			inherited[prefix] = uri
+		}
+	}
+	for prefix := range namespaceDeclarations(elem) {
+		delete(inherited, prefix)
+	}
+	if len(inherited) == 0 {
+		return raw
+	}
+
+	prefixes := []string{}
+	for prefix := range inherited {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	var decls strings.Builder
+	escaper := strings.NewReplacer("&", "&amp;", "<", "&lt;", "\"", "&quot;")
+	for _, prefix := range prefixes {
+		name := "xmlns"
+		if prefix != "" {
+			name = "xmlns:" + prefix
+		}
+		fmt.Fprintf(&decls, ` %s="%s"`, name, escaper.Replace(inherited[prefix]))
+	}
+
+	// after the element name
+	end := strings.IndexAny(raw, " \t\r\n/>")
+	return raw[:end] + decls.String() + raw[end:]
+}
@@ -0,0 +1,329 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	jbproj "jbextractor/jitterbit/project"
+)
+
+// Messaging topology files in the extracted project root.
+const (
+	JMS_TOPOLOGY_MD  = "jms-topology.md"
+	JMS_TOPOLOGY_DOT = "jms-topology.dot"
+)
+
+// Directions of JMS activities.
+const (
+	JMS_PUBLISH     = "publish"
+	JMS_CONSUME     = "consume"
+	JMS_BROWSE      = "browse"
+	JMS_ACKNOWLEDGE = "acknowledge"
+)
+
+// JMS activity types and their directions.
+var jmsActivityTypes = []struct {
+	typeName  string
+	direction string
+}{
+	{jbproj.JMS_SEND_PUBLISH, JMS_PUBLISH},
+	{jbproj.JMS_LISTEN, JMS_CONSUME},
+	{jbproj.JMS_POLL, JMS_CONSUME},
+	{jbproj.JMS_BROWSE, JMS_BROWSE},
+	{jbproj.JMS_ACK, JMS_ACKNOWLEDGE},
+}
+
+// Property keys of JMS settings.
+var (
+	jmsDestinationTypeKey = regexp.MustCompile(`(?i)destination.?type|domain`)
+	jmsDestinationKey     = regexp.MustCompile(`(?i)destination|queue|topic`)
+	jmsFactoryKey         = regexp.MustCompile(`(?i)factory`)
+	jmsSelectorKey        = regexp.MustCompile(`(?i)selector`)
+)
+
+// Sidecar metadata of an exported JMS activity.
+type JmsMetadata struct {
+	Metadata
+	// publish, consume, browse or acknowledge.
+	Direction string `json:"direction"`
+	// Endpoint entity path.
+	Endpoint string `json:"endpoint"`
+	// Provider URL with credentials removed.
+	Address     string `json:"address"`
+	Destination string `json:"destination"`
+	// queue or topic, empty if unknown.
+	DestinationType string `json:"destinationType"`
+	Selector        string `json:"selector,omitempty"`
+	// Paths of the operations using the activity.
+	Operations []string `json:"operations"`
+}
+
+// A queue or topic of the messaging topology.
+type jmsDestination struct {
+	name     string
+	kind     string
+	endpoint string
+	publish  []string
+	consume  []string
+	browse   []string
+}
+
+// Jms writes JMS endpoints and activities with a <name>.json sidecar naming the endpoint, destination and the
+// operations using each activity, and the messaging topology of the queues and topics as jms-topology.md with a
+// table and Mermaid graph and as jms-topology.dot. Provider URLs are added to the inventory for each activity.
+func (e *Exporter) Jms() error {
+	addresses := make(map[string]string)
+	endpoints, err := e.entities(jbproj.JMS_ENDPOINT)
+	if err != nil {
+		return err
+	}
+	for _, file := range endpoints {
+		addresses[file.entity.Header.Id] = entityEndpoint(file)
+		if err := writeJSON(file.base+".json", e.metadata(file)); err != nil {
+			return err
+		}
+	}
+
+	users, err := e.activityOperations()
+	if err != nil {
+		return err
+	}
+	destinations := make(map[string]*jmsDestination)
+	for _, activity := range jmsActivityTypes {
+		files, err := e.entities(activity.typeName)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			meta := JmsMetadata{Metadata: e.metadata(file), Direction: activity.direction, Operations: users[file.entity.Header.Id]}
+			if meta.Operations == nil {
+				meta.Operations = []string{}
+			}
+			for _, prop := range meta.Properties {
+				if prop.Masked || prop.Value == "" {
+					continue
+				}
+				switch {
+				case meta.Endpoint == "" && connectorEndpointKey.MatchString(prop.Key) && e.isEntity(prop.Value):
+					meta.Endpoint = e.entityRef(prop.Value)
+					meta.Address = addresses[prop.Value]
+				case jmsDestinationTypeKey.MatchString(prop.Key):
+					setOnce(&meta.DestinationType, destinationType(prop.Value))
+				case jmsSelectorKey.MatchString(prop.Key):
+					setOnce(&meta.Selector, prop.Value)
+				case jmsDestinationKey.MatchString(prop.Key) && !jmsFactoryKey.MatchString(prop.Key) && !e.isEntity(prop.Value):
+					setOnce(&meta.Destination, prop.Value)
+					setOnce(&meta.DestinationType, destinationType(prop.Key))
+				}
+			}
+			if meta.Endpoint == "" {
+				e.warn(file, "No endpoint found")
+			}
+			if err := writeJSON(file.base+".json", meta); err != nil {
+				return err
+			}
+			e.addEndpoint(file, meta.Destination, meta.Address)
+
+			// acknowledgements refer to received messages, usually without a destination
+			if meta.Destination == "" {
+				if activity.direction != JMS_ACKNOWLEDGE {
+					e.warn(file, "No destination found")
+				}
+				continue
+			}
+			key := meta.Endpoint + "\n" + meta.Destination
+			dest, ok := destinations[key]
+			if !ok {
+				dest = &jmsDestination{name: meta.Destination, endpoint: meta.Endpoint}
+				destinations[key] = dest
+			}
+			setOnce(&dest.kind, meta.DestinationType)
+			participants := meta.Operations
+			if len(participants) == 0 {
+				// unused activities are shown by themselves
+				participants = []string{fmt.Sprintf("%s/%s", file.entity.Type, file.entityPath())}
+			}
+			switch activity.direction {
+			case JMS_PUBLISH:
+				dest.publish = append(dest.publish, participants...)
+			case JMS_BROWSE:
+				dest.browse = append(dest.browse, participants...)
+			default:
+				dest.consume = append(dest.consume, participants...)
+			}
+		}
+	}
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	sorted := []*jmsDestination{}
+	for _, dest := range destinations {
+		for _, list := range []*[]string{&dest.publish, &dest.consume, &dest.browse} {
+			*list = uniqueSorted(*list)
+		}
+		sorted = append(sorted, dest)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].name != sorted[j].name {
+			return sorted[i].name < sorted[j].name
+		}
+		return sorted[i].endpoint < sorted[j].endpoint
+	})
+	if err := os.WriteFile(fmt.Sprintf("%s%s%s", e.out, e.sep, JMS_TOPOLOGY_MD), []byte(topologyMarkdown(sorted)), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(fmt.Sprintf("%s%s%s", e.out, e.sep, JMS_TOPOLOGY_DOT), []byte(topologyDot(sorted)), os.ModePerm)
+}
+
+// activityOperations returns the paths of the operations using each activity entity ID.
+func (e *Exporter) activityOperations() (map[string][]string, error) {
+	users := make(map[string][]string)
+	operations, err := e.readEntities(jbproj.OPERATION)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range operations {
+		path := fmt.Sprintf("%s/%s", jbproj.OPERATION, file.entityPath())
+		for _, act := range file.entity.Pipeline.Activities.Activities {
+			if act.ContentId != "" {
+				users[act.ContentId] = append(users[act.ContentId], path)
+			}
+		}
+	}
+	return users, nil
+}
+
+// destinationType returns queue or topic if the text names one of them.
+func destinationType(text string) string {
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "topic"):
+		return "topic"
+	case strings.Contains(lower, "queue"):
+		return "queue"
+	}
+	return ""
+}
+
+// label returns the destination name with its type and endpoint.
+func (dest *jmsDestination) label() string {
+	label := dest.name
+	if dest.kind != "" {
+		label = fmt.Sprintf("%s %s", dest.kind, label)
+	}
+	if dest.endpoint != "" {
+		label = fmt.Sprintf("%s (%s)", label, dest.endpoint)
+	}
+	return label
+}
+
+// topologyMarkdown returns the topology table and Mermaid graph.
+func topologyMarkdown(destinations []*jmsDestination) string {
+	var md strings.Builder
+	md.WriteString("# JMS topology\n\n")
+	md.WriteString("| Destination | Type | Endpoint | Publishers | Consumers | Browsers |\n|---|---|---|---|---|---|\n")
+	cell := func(values ...string) string {
+		escaped := []string{}
+		for _, value := range values {
+			if value != "" {
+				escaped = append(escaped, jbproj.EscapeMarkdown(value))
+			}
+		}
+		if len(escaped) == 0 {
+			return "-"
+		}
+		return strings.Join(escaped, "<br>")
+	}
+	for _, dest := range destinations {
+		fmt.Fprintf(&md, "| %s | %s | %s | %s | %s | %s |\n", cell(dest.name), cell(dest.kind), cell(dest.endpoint),
+			cell(dest.publish...), cell(dest.consume...), cell(dest.browse...))
+	}
+
+	md.WriteString("\n```mermaid\nflowchart LR\n")
+	nodes := topologyNodes(destinations)
+	mermaidText := strings.NewReplacer(`"`, "#quot;")
+	for _, name := range nodes.participants {
+		fmt.Fprintf(&md, "  %s[\"%s\"]\n", nodes.ids[name], mermaidText.Replace(name))
+	}
+	for idx, dest := range destinations {
+		fmt.Fprintf(&md, "  d%d[(\"%s\")]\n", idx, mermaidText.Replace(dest.label()))
+	}
+	for idx, dest := range destinations {
+		for _, name := range dest.publish {
+			fmt.Fprintf(&md, "  %s -->|publish| d%d\n", nodes.ids[name], idx)
+		}
+		for _, name := range dest.consume {
+			fmt.Fprintf(&md, "  d%d -->|consume| %s\n", idx, nodes.ids[name])
+		}
+		for _, name := range dest.browse {
+			fmt.Fprintf(&md, "  d%d -.->|browse| %s\n", idx, nodes.ids[name])
+		}
+	}
+	md.WriteString("```\n")
+	return md.String()
+}
+
+// topologyDot returns the topology as a Graphviz digraph.
+func topologyDot(destinations []*jmsDestination) string {
+	var dot strings.Builder
+	dot.WriteString("digraph jms {\n  rankdir=LR;\n  node [shape=box];\n")
+	nodes := topologyNodes(destinations)
+	for _, name := range nodes.participants {
+		fmt.Fprintf(&dot, "  %s [label=%q];\n", nodes.ids[name], name)
+	}
+	for idx, dest := range destinations {
+		fmt.Fprintf(&dot, "  d%d [label=%q, shape=cylinder];\n", idx, dest.label())
+	}
+	for idx, dest := range destinations {
+		for _, name := range dest.publish {
+			fmt.Fprintf(&dot, "  %s -> d%d [label=\"publish\"];\n", nodes.ids[name], idx)
+		}
+		for _, name := range dest.consume {
+			fmt.Fprintf(&dot, "  d%d -> %s [label=\"consume\"];\n", idx, nodes.ids[name])
+		}
+		for _, name := range dest.browse {
+			fmt.Fprintf(&dot, "  d%d -> %s [label=\"browse\", style=dashed];\n", idx, nodes.ids[name])
+		}
+	}
+	dot.WriteString("}\n")
+	return dot.String()
+}
+
+// Graph nodes of the operations and activities publishing to or consuming from destinations.
+type jmsNodes struct {
+	// Sorted participant paths.
+	participants []string
+	// Node IDs by participant path.
+	ids map[string]string
+}
+
+// topologyNodes assigns node IDs to the participants of the destinations.
+func topologyNodes(destinations []*jmsDestination) jmsNodes {
+	names := []string{}
+	for _, dest := range destinations {
+		names = append(append(append(names, dest.publish...), dest.consume...), dest.browse...)
+	}
+	nodes := jmsNodes{participants: uniqueSorted(names), ids: make(map[string]string)}
+	for idx, name := range nodes.participants {
+		nodes.ids[name] = fmt.Sprintf("p%d", idx)
+	}
+	return nodes
+}
+
+// uniqueSorted returns the sorted distinct values.
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool)
+	unique := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,178 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	jbproj "jbextractor/jitterbit/project"
+)
+
+// Property keys of Salesforce settings.
+var (
+	sfConnectorKey  = regexp.MustCompile(`(?i)connector|connection|endpoint.?id`)
+	sfObjectKey     = regexp.MustCompile(`(?i)object|table`)
+	sfExternalIdKey = regexp.MustCompile(`(?i)external.?id`)
+	sfBatchKey      = regexp.MustCompile(`(?i)batch|bulk|parallel|serial|concurrency|chunk|size`)
+	sfLoginKey      = regexp.MustCompile(`(?i)login|server|endpoint|url|host`)
+	// SOQL statements, the object follows FROM.
+	soql = regexp.MustCompile(`(?is)^\s*select\s.+?\sfrom\s+(\w+)`)
+)
+
+// Sidecar metadata of an exported Salesforce activity.
+type SalesforceMetadata struct {
+	Metadata
+	// Activity kind: query, create or upsert.
+	Action string `json:"action"`
+	// Connector entity path.
+	Connector string `json:"connector"`
+	Object    string `json:"object"`
+	// Query or mapping summary file name.
+	File       string `json:"file"`
+	ExternalId string `json:"externalId,omitempty"`
+	// Batch and bulk API settings.
+	Batch []Property `json:"batch,omitempty"`
+	// Mapped fields of create and upsert activities.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Salesforce writes Salesforce queries as <name>.soql, create and upsert activities as <name>.md mapping summaries
+// with the object, external ID field and batch settings, and all Salesforce entities with a <name>.json sidecar.
+// Connector credentials are masked, the connector login URL is added to the endpoint inventory for each activity.
+func (e *Exporter) Salesforce() error {
+	logins := make(map[string]string)
+	connectors, err := e.entities(jbproj.SALESFORCE_CONNECTOR)
+	if err != nil {
+		return err
+	}
+	for _, file := range connectors {
+		meta := e.metadata(file)
+		logins[file.entity.Header.Id] = findProperty(meta.Properties, sfLoginKey)
+		if err := writeJSON(file.base+".json", meta); err != nil {
+			return err
+		}
+	}
+
+	for _, action := range []struct {
+		name     string
+		typeName string
+	}{
+		{"query", jbproj.SALESFORCE_QUERY},
+		{"create", jbproj.SALESFORCE_CREATE},
+		{"upsert", jbproj.SALESFORCE_UPSERT},
+	} {
+		files, err := e.entities(action.typeName)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			meta := SalesforceMetadata{Metadata: e.metadata(file), Action: action.name}
+			connectorId := ""
+			for _, prop := range meta.Properties {
+				if !prop.Masked && sfConnectorKey.MatchString(prop.Key) && e.isEntity(prop.Value) {
+					connectorId = prop.Value
+					meta.Connector = e.entityRef(prop.Value)
+					break
+				}
+			}
+			meta.Object = findProperty(meta.Properties, sfObjectKey)
+
+			if action.name == "query" {
+				err = e.writeQuery(file, &meta)
+			} else {
+				err = e.writeMappingSummary(file, &meta)
+			}
+			if err != nil {
+				return err
+			}
+			if err := writeJSON(file.base+".json", meta); err != nil {
+				return err
+			}
+			e.addEndpoint(file, fmt.Sprintf("%s %s", action.name, meta.Object), logins[connectorId])
+		}
+	}
+	return nil
+}
+
+// writeQuery writes the SOQL statement of a query activity, the object is taken from its FROM clause if missing.
+func (e *Exporter) writeQuery(file *entityFile, meta *SalesforceMetadata) error {
+	query := ""
+	for _, prop := range meta.Properties {
+		if soql.MatchString(prop.Value) {
+			query = prop.Value
+			break
+		}
+	}
+	if query == "" {
+		query = findText(file.data, soql)
+	}
+	if query == "" {
+		e.warn(file, "No SOQL query found")
+		return nil
+	}
+
+	if match := soql.FindStringSubmatch(query); meta.Object == "" && match != nil {
+		meta.Object = match[1]
+	}
+	meta.File = jbproj.SanitizeFileName(file.entity.Header.Name) + ".soql"
+	return os.WriteFile(file.base+".soql", []byte(strings.TrimSpace(query)+"\n"), os.ModePerm)
+}
+
+// writeMappingSummary writes the Markdown summary of a create or upsert activity.
+func (e *Exporter) writeMappingSummary(file *entityFile, meta *SalesforceMetadata) error {
+	meta.ExternalId = findProperty(meta.Properties, sfExternalIdKey)
+	for _, prop := range meta.Properties {
+		if sfBatchKey.MatchString(prop.Key) {
+			meta.Batch = append(meta.Batch, prop)
+		}
+	}
+	meta.Fields = fieldNames(file.data)
+
+	rows := [][2]string{{"Action", meta.Action}, {"Object", meta.Object}}
+	if meta.Action == "upsert" {
+		rows = append(rows, [2]string{"External ID field", meta.ExternalId})
+	}
+	rows = append(rows, [2]string{"Connector", meta.Connector}, [2]string{"Folder", meta.Folder})
+	md := newSummary(file.entity.Header.Name, rows)
+	md.properties("Batch settings", meta.Batch, "Defaults.")
+	md.list("Fields", meta.Fields, "No field definitions, the fields are mapped by the transformation.")
+
+	meta.File = jbproj.SanitizeFileName(file.entity.Header.Name) + ".md"
+	return os.WriteFile(file.base+".md", []byte(md.String()), os.ModePerm)
+}
+
+// findProperty returns the first unmasked non-empty value of a property with a matching key.
+func findProperty(props []Property, key *regexp.Regexp) string {
+	for _, prop := range props {
+		if !prop.Masked && prop.Value != "" && key.MatchString(prop.Key) {
+			return prop.Value
+		}
+	}
+	return ""
+}
+
+// findText returns the first element text or attribute value of an entity file matching the pattern.
+func findText(data []byte, pattern *regexp.Regexp) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			for _, attr := range t.Attr {
+				if pattern.MatchString(attr.Value) {
+					return attr.Value
+				}
+			}
+		case xml.CharData:
+			if pattern.MatchString(string(t)) {
+				return string(t)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	jbproj "jbextractor/jitterbit/project"
+)
+
+// A Markdown summary of an activity configuration.
+type summary struct {
+	md strings.Builder
+}
+
+// newSummary starts a summary with the entity name and a table of its main settings, empty values are shown as -.
+func newSummary(name string, rows [][2]string) *summary {
+	s := &summary{}
+	fmt.Fprintf(&s.md, "# %s\n\n", jbproj.EscapeMarkdown(name))
+	s.md.WriteString("| | |\n|---|---|\n")
+	for _, row := range rows {
+		value := jbproj.EscapeMarkdown(row[1])
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(&s.md, "| %s | %s |\n", row[0], value)
+	}
+	return s
+}
+
+// properties adds a section with a table of properties, or the text if there are none.
+func (s *summary) properties(title string, props []Property, none string) {
+	fmt.Fprintf(&s.md, "\n## %s\n\n", title)
+	if len(props) == 0 {
+		s.md.WriteString(none + "\n")
+		return
+	}
+	s.md.WriteString("| Setting | Value |\n|---|---|\n")
+	for _, prop := range props {
+		value := strings.ReplaceAll(jbproj.EscapeMarkdown(prop.Value), "\n", "<br>")
+		fmt.Fprintf(&s.md, "| %s | %s |\n", jbproj.EscapeMarkdown(prop.Key), value)
+	}
+}
+
+// list adds a section with a bulleted list, or the text if there are no items.
+func (s *summary) list(title string, items []string, none string) {
+	fmt.Fprintf(&s.md, "\n## %s\n\n", title)
+	if len(items) == 0 {
+		s.md.WriteString(none + "\n")
+	}
+	for _, item := range items {
+		fmt.Fprintf(&s.md, "- %s\n", jbproj.EscapeMarkdown(item))
+	}
+}
+
+// code adds a section with a code block.
+func (s *summary) code(title string, text string) {
+	fmt.Fprintf(&s.md, "\n## %s\n\n```\n%s\n```\n", title, strings.TrimSpace(text))
+}
+
+// String returns the Markdown text.
+func (s *summary) String() string {
+	return s.md.String()
+}
+
+// fieldNames returns the dot-separated paths of the leaf fields defined in an entity file.
+func fieldNames(data []byte) []string {
+	names := []string{}
+	var addFields func(fields []*field, prefix string)
+	addFields = func(fields []*field, prefix string) {
+		for _, f := range fields {
+			if len(f.children) > 0 {
+				addFields(f.children, prefix+f.name+".")
+				continue
+			}
+			names = append(names, prefix+f.name)
+		}
+	}
+	addFields(parseFields(data), "")
+	return names
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"encoding/xml"
+	"os"
+	"regexp"
+	"strings"
+
+	jbproj "jbextractor/jitterbit/project"
+)
+
+const (
+	WSDL_NAMESPACE   = "http://schemas.xmlsoap.org/wsdl/"
+	WSDL20_NAMESPACE = "http://www.w3.org/ns/wsdl"
+)
+
+// Property keys of web service call settings.
+var (
+	wsOperationKey  = regexp.MustCompile(`(?i)operation`)
+	wsSoapActionKey = regexp.MustCompile(`(?i)soap.?action`)
+	wsRequestKey    = regexp.MustCompile(`(?i)request|input`)
+	wsResponseKey   = regexp.MustCompile(`(?i)response|output`)
+)
+
+// Sidecar metadata of an exported web service call.
+type WebServiceMetadata struct {
+	Metadata
+	// WSDL file name, empty if the entity contains no WSDL.
+	Wsdl string `json:"wsdl"`
+	// Selected WSDL operation.
+	Operation  string `json:"operation"`
+	SoapAction string `json:"soapAction"`
+	// Endpoint URL with credentials removed.
+	Endpoint string `json:"endpoint"`
+	// Request structure, a project entity path or the WSDL message element.
+	Request string `json:"request"`
+	// Response structure, a project entity path or the WSDL message element.
+	Response string `json:"response"`
+}
+
+// The parts of a WSDL 1.1 document describing operations and endpoints.
+type wsdlDefinitions struct {
+	Messages []struct {
+		Name  string `xml:"name,attr"`
+		Parts []struct {
+			Element string `xml:"element,attr"`
+			Type    string `xml:"type,attr"`
+		} `xml:"part"`
+	} `xml:"message"`
+	PortTypes []struct {
+		Operations []struct {
+			Name   string      `xml:"name,attr"`
+			Input  wsdlMessage `xml:"input"`
+			Output wsdlMessage `xml:"output"`
+		} `xml:"operation"`
+	} `xml:"portType"`
+	Bindings []struct {
+		Operations []struct {
+			Name string `xml:"name,attr"`
+			Soap struct {
+				SoapAction string `xml:"soapAction,attr"`
+			} `xml:"operation"`
+		} `xml:"operation"`
+	} `xml:"binding"`
+	Services []struct {
+		Ports []struct {
+			Address struct {
+				Location string `xml:"location,attr"`
+			} `xml:"address"`
+		} `xml:"port"`
+	} `xml:"service"`
+}
+
+// Input or output message reference of a WSDL operation.
+type wsdlMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// WebServiceCalls writes the embedded WSDL of each web service call as <name>.wsdl with a <name>.json sidecar
+// describing the selected operation, SOAP action, endpoint URL and request and response structures.
+// The endpoints are added to the inventory.
+func (e *Exporter) WebServiceCalls() error {
+	files, err := e.entities(jbproj.WS_CALL)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		meta := WebServiceMetadata{Metadata: e.metadata(file)}
+		for _, prop := range meta.Properties {
+			if prop.Masked || prop.Value == "" {
+				continue
+			}
+			switch {
+			case wsSoapActionKey.MatchString(prop.Key):
+				setOnce(&meta.SoapAction, prop.Value)
+			case wsOperationKey.MatchString(prop.Key) && !e.isEntity(prop.Value):
+				setOnce(&meta.Operation, prop.Value)
+			case wsRequestKey.MatchString(prop.Key) && e.isEntity(prop.Value):
+				setOnce(&meta.Request, e.entityRef(prop.Value))
+			case wsResponseKey.MatchString(prop.Key) && e.isEntity(prop.Value):
+				setOnce(&meta.Response, e.entityRef(prop.Value))
+			}
+		}
+		meta.Endpoint = propertyEndpoint(meta.Properties)
+
+		wsdl, ok := findEmbedded(file.data, WSDL_NAMESPACE, "definitions")
+		if !ok {
+			wsdl, ok = findEmbedded(file.data, WSDL20_NAMESPACE, "description")
+		}
+		if ok {
+			wsdl = redactLocations(wsdl)
+			meta.Wsdl = jbproj.SanitizeFileName(file.entity.Header.Name) + ".wsdl"
+			if err := os.WriteFile(file.base+".wsdl", []byte(wsdl), os.ModePerm); err != nil {
+				return err
+			}
+			describeWsdl(wsdl, &meta)
+		} else {
+			e.warn(file, "No WSDL found")
+		}
+		meta.Endpoint = redactURL(meta.Endpoint)
+
+		if err := writeJSON(file.base+".json", meta); err != nil {
+			return err
+		}
+		e.addEndpoint(file, meta.Operation, meta.Endpoint)
+	}
+	return nil
+}
+
+// describeWsdl completes the settings missing from the entity properties with the WSDL 1.1 definitions:
+// the only operation, its SOAP action and messages and the first service address.
+func describeWsdl(wsdl string, meta *WebServiceMetadata) {
+	var defs wsdlDefinitions
+	if xml.Unmarshal([]byte(wsdl), &defs) != nil {
+		return
+	}
+
+	operations := []string{}
+	for _, portType := range defs.PortTypes {
+		for _, op := range portType.Operations {
+			operations = append(operations, op.Name)
+			if op.Name == meta.Operation || (meta.Operation == "" && len(defs.PortTypes) == 1 && len(portType.Operations) == 1) {
+				setOnce(&meta.Operation, op.Name)
+				setOnce(&meta.Request, defs.messageElement(op.Input.Message))
+				setOnce(&meta.Response, defs.messageElement(op.Output.Message))
+			}
+		}
+	}
+	for _, binding := range defs.Bindings {
+		for _, op := range binding.Operations {
+			if op.Name == meta.Operation {
+				setOnce(&meta.SoapAction, op.Soap.SoapAction)
+			}
+		}
+	}
+	for _, service := range defs.Services {
+		for _, port := range service.Ports {
+			setOnce(&meta.Endpoint, port.Address.Location)
+		}
+	}
+}
+
+// messageElement returns the element or type of the first part of a message, e.g. tns:GetOrderRequest.
+func (defs *wsdlDefinitions) messageElement(qname string) string {
+	name := qname[strings.Index(qname, ":")+1:]
+	for _, msg := range defs.Messages {
+		if msg.Name != name || len(msg.Parts) == 0 {
+			continue
+		}
+		if msg.Parts[0].Element != "" {
+			return msg.Parts[0].Element
+		}
+		return msg.Parts[0].Type
+	}
+	return ""
+}
+
+// isEntity reports whether a value is the ID of a project entity.
+func (e *Exporter) isEntity(value string) bool {
+	_, ent, _ := e.project.FindEntity(value)
+	return ent != nil
+}
+
+// entityRef returns the path of an entity ID, e.g. Document/Folder/Name.
+func (e *Exporter) entityRef(id string) string {
+	et, ent, folders := e.project.FindEntity(id)
+	if ent == nil {
+		return id
+	}
+	return strings.Join(append(append([]string{et.Name}, folders...), ent.Name), "/")
+}
+
+// setOnce sets an empty setting.
+func setOnce(setting *string, value string) {
+	if *setting == "" {
+		*setting = value
+	}
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	"os"
+
+	jbproj "jbextractor/jitterbit/project"
+)
+
+const XSL_NAMESPACE = "http://www.w3.org/1999/XSL/Transform"
+
+// Sidecar metadata of an exported XSLT transform.
+type XsltMetadata struct {
+	Metadata
+	// Stylesheet file name, empty if the entity contains no stylesheet.
+	Stylesheet string `json:"stylesheet"`
+}
+
+// XsltTransforms writes each XSLT transform as a standalone <name>.xsl stylesheet with a <name>.json metadata sidecar.
+func (e *Exporter) XsltTransforms() error {
+	files, err := e.entities(jbproj.XSLT_TRANSFORM)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		meta := XsltMetadata{Metadata: e.metadata(file)}
+		stylesheet, ok := findStylesheet(file.data)
+		if ok {
+			meta.Stylesheet = jbproj.SanitizeFileName(file.entity.Header.Name) + ".xsl"
+			if err := os.WriteFile(file.base+".xsl", []byte(stylesheet), os.ModePerm); err != nil {
+				return err
+			}
+		} else {
+			e.warn(file, "No XSLT stylesheet found")
+		}
+		if err := writeJSON(file.base+".json", meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findStylesheet returns the first XSLT stylesheet of an entity file.
+func findStylesheet(data []byte) (string, bool) {
+	return findEmbedded(data, XSL_NAMESPACE, "stylesheet", "transform")
+}
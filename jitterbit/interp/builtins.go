@@ -0,0 +1,841 @@
+package interp
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	mrand "math/rand"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"jbextractor/jitterbit/script"
+)
+
+// Built-in function evaluated with its argument values.
+type builtinFunc func(in *Interpreter, args []Value) (Value, error)
+
+// Built-in function evaluating its arguments itself.
+type controlFunc func(in *Interpreter, fr *frame, call *script.Call) (Value, error)
+
+// Control functions with lazily evaluated arguments.
+var controlBuiltins map[string]controlFunc
+
+// Built-in functions supported by the interpreter, the argument counts are checked against the catalog.
+var builtins map[string]builtinFunc
+
+func init() {
+	controlBuiltins = map[string]controlFunc{
+		"If":           controlIf,
+		"Case":         controlCase,
+		"While":        controlWhile,
+		"Eval":         controlEval,
+		"IsValid":      controlIsValid,
+		"RaiseError":   controlRaiseError,
+		"ArgumentList": controlArgumentList,
+	}
+
+	builtins = map[string]builtinFunc{
+		// Array and dictionary
+		"AddToDict":  addToDict,
+		"Array":      func(in *Interpreter, args []Value) (Value, error) { return NewArray(), nil },
+		"Collection": func(in *Interpreter, args []Value) (Value, error) { return NewArray(), nil },
+		"Dict":       func(in *Interpreter, args []Value) (Value, error) { return NewDict(), nil },
+		"HasKey":     hasKey,
+		"Length":     length,
+		"SortArray":  sortArray,
+		// Conversion
+		"Bool":   func(in *Interpreter, args []Value) (Value, error) { return ToBool(args[0]), nil },
+		"Date":   func(in *Interpreter, args []Value) (Value, error) { return ToDate(args[0]) },
+		"Double": toDouble,
+		"Float":  toDouble,
+		"Int":    toInt,
+		"Long":   toInt,
+		"String": func(in *Interpreter, args []Value) (Value, error) { return ToString(args[0]), nil },
+		// Cryptography
+		"Base64Encode": func(in *Interpreter, args []Value) (Value, error) {
+			return base64.StdEncoding.EncodeToString([]byte(ToString(args[0]))), nil
+		},
+		"MD5": func(in *Interpreter, args []Value) (Value, error) {
+			sum := md5.Sum([]byte(ToString(args[0])))
+			return hex.EncodeToString(sum[:]), nil
+		},
+		"SHA1": func(in *Interpreter, args []Value) (Value, error) {
+			sum := sha1.Sum([]byte(ToString(args[0])))
+			return hex.EncodeToString(sum[:]), nil
+		},
+		"SHA256": func(in *Interpreter, args []Value) (Value, error) {
+			sum := sha256.Sum256([]byte(ToString(args[0])))
+			return hex.EncodeToString(sum[:]), nil
+		},
+		// Date and time
+		"CVTDate":        cvtDate,
+		"DateAdd":        dateAdd,
+		"DayOfMonth":     datePart(func(date time.Time) Value { return int64(date.Day()) }),
+		"DayOfWeek":      datePart(func(date time.Time) Value { return int64(date.Weekday()) + 1 }),
+		"FormatDate":     formatDate,
+		"GeneralDate":    dateLayout("01/02/2006 03:04:05 PM"),
+		"LastDayOfMonth": datePart(lastDayOfMonth),
+		"LongDate":       dateLayout("Monday, January 02, 2006"),
+		"LongTime":       dateLayout("03:04:05 PM"),
+		"MediumDate":     dateLayout("02-Jan-06"),
+		"MediumTime":     dateLayout("03:04 PM"),
+		"MonthOfYear":    datePart(func(date time.Time) Value { return int64(date.Month()) }),
+		"Now":            func(in *Interpreter, args []Value) (Value, error) { return in.Now().Truncate(time.Second), nil },
+		"Now_":           func(in *Interpreter, args []Value) (Value, error) { return in.Now(), nil },
+		"ShortDate":      dateLayout("01/02/06"),
+		"ShortTime":      dateLayout("15:04"),
+		// Environment
+		"GetLastError":   func(in *Interpreter, args []Value) (Value, error) { return in.lastError, nil },
+		"ResetLastError": func(in *Interpreter, args []Value) (Value, error) { in.lastError = ""; return nil, nil },
+		"SetLastError": func(in *Interpreter, args []Value) (Value, error) {
+			in.lastError = ToString(args[0])
+			return nil, nil
+		},
+		"WriteToOperationLog": func(in *Interpreter, args []Value) (Value, error) {
+			msg := ToString(args[0])
+			in.Log = append(in.Log, msg)
+			return msg, nil
+		},
+		// General
+		"Get":          get,
+		"GUID":         guid,
+		"IfEmpty":      ifEmpty,
+		"IfNull":       ifNull,
+		"InList":       inList,
+		"IsInteger":    isInteger,
+		"IsNull":       func(in *Interpreter, args []Value) (Value, error) { return args[0] == nil, nil },
+		"Null":         func(in *Interpreter, args []Value) (Value, error) { return nil, nil },
+		"Random":       random,
+		"RandomString": randomString,
+		"RunScript":    runScript,
+		"Set":          set,
+		"Sleep":        func(in *Interpreter, args []Value) (Value, error) { return nil, nil },
+		// Logical
+		"Equal": func(in *Interpreter, args []Value) (Value, error) { return DeepEqual(args[0], args[1]), nil },
+		// Math
+		"Ceiling":    mathFunc(math.Ceil),
+		"Exp":        mathFunc(math.Exp),
+		"Floor":      mathFunc(math.Floor),
+		"Log":        mathFunc(math.Log),
+		"Log10":      mathFunc(math.Log10),
+		"Mod":        mod,
+		"Pow":        pow,
+		"Round":      round,
+		"RoundToInt": mathFunc(math.Round),
+		"Sqrt":       mathFunc(math.Sqrt),
+		// String
+		"CountSubString": countSubString,
+		"DQuote":         func(in *Interpreter, args []Value) (Value, error) { return `"` + ToString(args[0]) + `"`, nil },
+		"Index":          index,
+		"Left":           left,
+		"LPad":           pad(true),
+		"LPadChar":       padChar(true),
+		"LTrim":          stringFunc(func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) }),
+		"LTrimChars":     trimChars(strings.TrimLeft),
+		"Mid":            mid,
+		"Quote":          func(in *Interpreter, args []Value) (Value, error) { return "'" + ToString(args[0]) + "'", nil },
+		"RegExMatch":     regExMatch,
+		"RegExReplace":   regExReplace,
+		"Replace":        replace,
+		"Right":          right,
+		"RPad":           pad(false),
+		"RPadChar":       padChar(false),
+		"RTrim":          stringFunc(func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) }),
+		"RTrimChars":     trimChars(strings.TrimRight),
+		"Split":          split,
+		"StringLength":   func(in *Interpreter, args []Value) (Value, error) { return int64(len([]rune(ToString(args[0])))), nil },
+		"ToLower":        stringFunc(strings.ToLower),
+		"ToProper":       stringFunc(toProper),
+		"ToUpper":        stringFunc(strings.ToUpper),
+		"Trim":           stringFunc(strings.TrimSpace),
+		"TrimChars":      trimChars(strings.Trim),
+		"Truncate":       truncate,
+		"URLDecode":      urlDecode,
+		"URLEncode":      func(in *Interpreter, args []Value) (Value, error) { return url.QueryEscape(ToString(args[0])), nil },
+	}
+}
+
+// controlIf evaluates only the selected branch.
+func controlIf(in *Interpreter, fr *frame, call *script.Call) (Value, error) {
+	cond, err := in.eval(fr, call.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	if ToBool(cond) {
+		return in.eval(fr, call.Args[1])
+	}
+	if len(call.Args) > 2 {
+		return in.eval(fr, call.Args[2])
+	}
+	return nil, nil
+}
+
+// controlCase evaluates condition and value pairs until a condition is true.
+func controlCase(in *Interpreter, fr *frame, call *script.Call) (Value, error) {
+	for idx := 0; idx+1 < len(call.Args); idx += 2 {
+		cond, err := in.eval(fr, call.Args[idx])
+		if err != nil {
+			return nil, err
+		}
+		if ToBool(cond) {
+			return in.eval(fr, call.Args[idx+1])
+		}
+	}
+	return nil, nil
+}
+
+// controlWhile repeats the body while the condition is true, up to the iteration limit.
+func controlWhile(in *Interpreter, fr *frame, call *script.Call) (Value, error) {
+	limit := int64(MAX_ITERATIONS)
+	if len(call.Args) > 2 {
+		value, err := in.eval(fr, call.Args[2])
+		if err != nil {
+			return nil, err
+		}
+		if limit, err = ToInt(value); err != nil {
+			return nil, fail(call.Args[2], err.Error())
+		}
+	}
+
+	for count := int64(0); ; count++ {
+		cond, err := in.eval(fr, call.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		if !ToBool(cond) {
+			return nil, nil
+		}
+		if count >= limit {
+			return nil, fail(call, "While loop exceeded %d iterations", limit)
+		}
+		if _, err := in.eval(fr, call.Args[1]); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// controlEval returns the default value if the expression fails.
+func controlEval(in *Interpreter, fr *frame, call *script.Call) (Value, error) {
+	value, err := in.eval(fr, call.Args[0])
+	if err == nil {
+		return value, nil
+	}
+	in.lastError = errorMessage(err)
+	return in.eval(fr, call.Args[1])
+}
+
+// controlIsValid reports whether the expression evaluates without errors.
+func controlIsValid(in *Interpreter, fr *frame, call *script.Call) (Value, error) {
+	_, err := in.eval(fr, call.Args[0])
+	return err == nil, nil
+}
+
+// controlRaiseError stops the script with the message.
+func controlRaiseError(in *Interpreter, fr *frame, call *script.Call) (Value, error) {
+	value, err := in.eval(fr, call.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	in.lastError = ToString(value)
+	return nil, &RuntimeError{Pos: call.Pos(), Msg: in.lastError, Raised: true}
+}
+
+// controlArgumentList assigns the RunScript arguments to local variables.
+func controlArgumentList(in *Interpreter, fr *frame, call *script.Call) (Value, error) {
+	for idx, arg := range call.Args {
+		ident, ok := arg.(*script.Ident)
+		if !ok {
+			return nil, fail(arg, "ArgumentList expects local variable names")
+		}
+		var value Value
+		if idx < len(fr.args) {
+			value = fr.args[idx]
+		}
+		fr.locals[ident.Name] = value
+	}
+	return nil, nil
+}
+
+// errorMessage returns an error message without its position.
+func errorMessage(err error) string {
+	var runtimeErr *RuntimeError
+	if errors.As(err, &runtimeErr) {
+		return runtimeErr.Msg
+	}
+	return err.Error()
+}
+
+func addToDict(in *Interpreter, args []Value) (Value, error) {
+	dict, ok := args[0].(*Dict)
+	if !ok {
+		return nil, fmt.Errorf("expected a dictionary, got %s", typeName(args[0]))
+	}
+	key := ToString(args[1])
+	_, exists := dict.Entries[key]
+	dict.Entries[key] = args[2]
+	return exists, nil
+}
+
+func hasKey(in *Interpreter, args []Value) (Value, error) {
+	dict, ok := args[0].(*Dict)
+	if !ok {
+		return nil, fmt.Errorf("expected a dictionary, got %s", typeName(args[0]))
+	}
+	_, exists := dict.Entries[ToString(args[1])]
+	return exists, nil
+}
+
+func length(in *Interpreter, args []Value) (Value, error) {
+	switch v := args[0].(type) {
+	case *Array:
+		return int64(len(v.Elems)), nil
+	case *Dict:
+		return int64(len(v.Entries)), nil
+	}
+	return int64(len([]rune(ToString(args[0])))), nil
+}
+
+func sortArray(in *Interpreter, args []Value) (Value, error) {
+	arr, ok := args[0].(*Array)
+	if !ok {
+		return nil, fmt.Errorf("expected an array, got %s", typeName(args[0]))
+	}
+
+	column := int64(-1)
+	if len(args) > 1 {
+		var err error
+		if column, err = ToInt(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	descending := len(args) > 2 && ToBool(args[2])
+
+	key := func(value Value) Value {
+		if row, ok := value.(*Array); ok && column >= 0 && column < int64(len(row.Elems)) {
+			return row.Elems[column]
+		}
+		return value
+	}
+	sort.SliceStable(arr.Elems, func(i, j int) bool {
+		cmp := Compare(key(arr.Elems[i]), key(arr.Elems[j]))
+		if descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+	return nil, nil
+}
+
+func toDouble(in *Interpreter, args []Value) (Value, error) {
+	return ToFloat(args[0])
+}
+
+func toInt(in *Interpreter, args []Value) (Value, error) {
+	return ToInt(args[0])
+}
+
+// Date format tokens of FormatDate and CVTDate, longest first.
+var dateTokens = []struct {
+	token  string
+	layout string
+}{
+	{"yyyy", "2006"},
+	{"Month", "January"},
+	{"Mon", "Jan"},
+	{"yy", "06"},
+	{"mm", "01"},
+	{"dd", "02"},
+	{"HH", "15"},
+	{"MM", "04"},
+	{"SS", "05"},
+}
+
+// goLayout converts a Jitterbit date format, e.g. yyyy-mm-dd HH:MM:SS, to a Go time layout.
+func goLayout(format string) string {
+	var out strings.Builder
+	for idx := 0; idx < len(format); {
+		matched := false
+		for _, tok := range dateTokens {
+			if strings.HasPrefix(format[idx:], tok.token) {
+				out.WriteString(tok.layout)
+				idx += len(tok.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			out.WriteByte(format[idx])
+			idx++
+		}
+	}
+	return out.String()
+}
+
+func formatDate(in *Interpreter, args []Value) (Value, error) {
+	date, err := ToDate(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return date.Format(goLayout(ToString(args[1]))), nil
+}
+
+func cvtDate(in *Interpreter, args []Value) (Value, error) {
+	date, err := time.Parse(goLayout(ToString(args[1])), ToString(args[0]))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %q as %s", ToString(args[0]), ToString(args[1]))
+	}
+	return date.Format(goLayout(ToString(args[2]))), nil
+}
+
+// dateLayout returns a function formatting its date argument with the Go layout.
+func dateLayout(layout string) builtinFunc {
+	return func(in *Interpreter, args []Value) (Value, error) {
+		date, err := ToDate(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return date.Format(layout), nil
+	}
+}
+
+// datePart returns a function extracting a value from its date argument.
+func datePart(part func(date time.Time) Value) builtinFunc {
+	return func(in *Interpreter, args []Value) (Value, error) {
+		date, err := ToDate(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return part(date), nil
+	}
+}
+
+func lastDayOfMonth(date time.Time) Value {
+	return time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, date.Location())
+}
+
+func dateAdd(in *Interpreter, args []Value) (Value, error) {
+	number, err := ToInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	date, err := ToDate(args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	n := int(number)
+	switch ToString(args[0]) {
+	case "yyyy":
+		return date.AddDate(n, 0, 0), nil
+	case "q":
+		return date.AddDate(0, 3*n, 0), nil
+	case "m":
+		return date.AddDate(0, n, 0), nil
+	case "y", "d", "w":
+		return date.AddDate(0, 0, n), nil
+	case "ww":
+		return date.AddDate(0, 0, 7*n), nil
+	case "h":
+		return date.Add(time.Duration(n) * time.Hour), nil
+	case "n":
+		return date.Add(time.Duration(n) * time.Minute), nil
+	case "s":
+		return date.Add(time.Duration(n) * time.Second), nil
+	}
+	return nil, fmt.Errorf("unknown date part %q", ToString(args[0]))
+}
+
+func get(in *Interpreter, args []Value) (Value, error) {
+	value := in.Globals[globalName(args[0])]
+	for _, idx := range args[1:] {
+		arr, ok := value.(*Array)
+		if !ok {
+			return nil, nil
+		}
+		pos, err := ToInt(idx)
+		if err != nil {
+			return nil, err
+		}
+		if pos < 0 || pos >= int64(len(arr.Elems)) {
+			return nil, nil
+		}
+		value = arr.Elems[pos]
+	}
+	return value, nil
+}
+
+func set(in *Interpreter, args []Value) (Value, error) {
+	name := globalName(args[0])
+	value := args[1]
+	if len(args) == 2 {
+		in.Globals[name] = value
+		return value, nil
+	}
+
+	// Set(name, value, i, j) assigns an element of a nested array
+	arr, ok := in.Globals[name].(*Array)
+	if !ok {
+		arr = NewArray()
+		in.Globals[name] = arr
+	}
+	for idx, arg := range args[2:] {
+		pos, err := ToInt(arg)
+		if err != nil || pos < 0 {
+			return nil, fmt.Errorf("invalid array index %s", ToString(arg))
+		}
+		for int64(len(arr.Elems)) <= pos {
+			arr.Elems = append(arr.Elems, nil)
+		}
+		if idx == len(args)-3 {
+			arr.Elems[pos] = value
+			break
+		}
+		next, ok := arr.Elems[pos].(*Array)
+		if !ok {
+			next = NewArray()
+			arr.Elems[pos] = next
+		}
+		arr = next
+	}
+	return value, nil
+}
+
+func guid(in *Interpreter, args []Value) (Value, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+	data[6] = (data[6] & 0x0f) | 0x40
+	data[8] = (data[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", data[0:4], data[4:6], data[6:8], data[8:10], data[10:]), nil
+}
+
+func ifEmpty(in *Interpreter, args []Value) (Value, error) {
+	if ToString(args[0]) == "" {
+		return args[1], nil
+	}
+	return args[0], nil
+}
+
+func ifNull(in *Interpreter, args []Value) (Value, error) {
+	if args[0] == nil {
+		return args[1], nil
+	}
+	return args[0], nil
+}
+
+func inList(in *Interpreter, args []Value) (Value, error) {
+	for idx, arg := range args[1:] {
+		if Compare(args[0], arg) == 0 {
+			return int64(idx + 1), nil
+		}
+	}
+	return int64(0), nil
+}
+
+func isInteger(in *Interpreter, args []Value) (Value, error) {
+	switch v := args[0].(type) {
+	case int64:
+		return true, nil
+	case float64:
+		return v == math.Trunc(v), nil
+	case string:
+		number, err := ToFloat(v)
+		return err == nil && strings.TrimSpace(v) != "" && number == math.Trunc(number), nil
+	}
+	return false, nil
+}
+
+func random(in *Interpreter, args []Value) (Value, error) {
+	min, err := ToInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	max, err := ToInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if max < min {
+		return nil, errors.New("maximum is less than minimum")
+	}
+	return min + mrand.Int63n(max-min+1), nil
+}
+
+func randomString(in *Interpreter, args []Value) (Value, error) {
+	count, err := ToInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("negative length %d", count)
+	}
+	chars := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789")
+	if len(args) > 1 && ToString(args[1]) != "" {
+		chars = []rune(ToString(args[1]))
+	}
+	result := make([]rune, count)
+	for idx := range result {
+		result[idx] = chars[mrand.Intn(len(chars))]
+	}
+	return string(result), nil
+}
+
+func runScript(in *Interpreter, args []Value) (Value, error) {
+	if in.Load == nil {
+		return nil, errors.New("no script loader is configured, RunScript must be mocked")
+	}
+	src, err := in.Load(ToString(args[0]))
+	if err != nil {
+		return nil, err
+	}
+	return in.Run(src, args[1:]...)
+}
+
+// mathFunc returns a function applying a float operation to its argument.
+func mathFunc(op func(float64) float64) builtinFunc {
+	return func(in *Interpreter, args []Value) (Value, error) {
+		x, err := ToFloat(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return normalizeNumber(op(x)), nil
+	}
+}
+
+func mod(in *Interpreter, args []Value) (Value, error) {
+	return operate(script.MOD, args[0], args[1])
+}
+
+func pow(in *Interpreter, args []Value) (Value, error) {
+	return operate(script.POW, args[0], args[1])
+}
+
+func round(in *Interpreter, args []Value) (Value, error) {
+	x, err := ToFloat(args[0])
+	if err != nil {
+		return nil, err
+	}
+	decimals := int64(0)
+	if len(args) > 1 {
+		if decimals, err = ToInt(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(x*scale) / scale, nil
+}
+
+// stringFunc returns a function applying a string operation to its argument.
+func stringFunc(op func(string) string) builtinFunc {
+	return func(in *Interpreter, args []Value) (Value, error) {
+		return op(ToString(args[0])), nil
+	}
+}
+
+// trimChars returns a function removing the characters of the second argument.
+func trimChars(op func(string, string) string) builtinFunc {
+	return func(in *Interpreter, args []Value) (Value, error) {
+		return op(ToString(args[0]), ToString(args[1])), nil
+	}
+}
+
+func countSubString(in *Interpreter, args []Value) (Value, error) {
+	sub := ToString(args[1])
+	if sub == "" {
+		return int64(0), nil
+	}
+	return int64(strings.Count(ToString(args[0]), sub)), nil
+}
+
+func index(in *Interpreter, args []Value) (Value, error) {
+	str := []rune(ToString(args[0]))
+	sub := []rune(ToString(args[1]))
+	n := int64(1)
+	if len(args) > 2 {
+		var err error
+		if n, err = ToInt(args[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	// negative occurrences count from the end
+	found := []int{}
+	for idx := 0; idx+len(sub) <= len(str); idx++ {
+		if string(str[idx:idx+len(sub)]) == string(sub) {
+			found = append(found, idx)
+		}
+	}
+	switch {
+	case n > 0 && n <= int64(len(found)):
+		return int64(found[n-1]), nil
+	case n < 0 && -n <= int64(len(found)):
+		return int64(found[int64(len(found))+n]), nil
+	}
+	return int64(-1), nil
+}
+
+// substring returns the runes in [from, to) clamped to the string.
+func substring(str []rune, from int64, to int64) string {
+	if from < 0 {
+		from = 0
+	}
+	if to > int64(len(str)) {
+		to = int64(len(str))
+	}
+	if from >= to {
+		return ""
+	}
+	return string(str[from:to])
+}
+
+func left(in *Interpreter, args []Value) (Value, error) {
+	n, err := ToInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return substring([]rune(ToString(args[0])), 0, n), nil
+}
+
+func right(in *Interpreter, args []Value) (Value, error) {
+	n, err := ToInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	str := []rune(ToString(args[0]))
+	return substring(str, int64(len(str))-n, int64(len(str))), nil
+}
+
+func mid(in *Interpreter, args []Value) (Value, error) {
+	m, err := ToInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	n, err := ToInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	return substring([]rune(ToString(args[0])), m, m+n), nil
+}
+
+func truncate(in *Interpreter, args []Value) (Value, error) {
+	first, err := ToInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	last, err := ToInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	str := []rune(ToString(args[0]))
+	return substring(str, first, int64(len(str))-last), nil
+}
+
+// pad returns LPad or RPad, which pad with spaces or a string and truncate longer values.
+func pad(leftSide bool) builtinFunc {
+	return func(in *Interpreter, args []Value) (Value, error) {
+		n, err := ToInt(args[1])
+		if err != nil {
+			return nil, err
+		}
+		filler := " "
+		if len(args) > 2 && ToString(args[2]) != "" {
+			filler = ToString(args[2])
+		}
+		return padString(ToString(args[0]), n, filler, leftSide), nil
+	}
+}
+
+// padChar returns LPadChar or RPadChar, which take the padding character before the length.
+func padChar(leftSide bool) builtinFunc {
+	return func(in *Interpreter, args []Value) (Value, error) {
+		n, err := ToInt(args[2])
+		if err != nil {
+			return nil, err
+		}
+		filler := ToString(args[1])
+		if filler == "" {
+			filler = " "
+		}
+		return padString(ToString(args[0]), n, filler, leftSide), nil
+	}
+}
+
+// padString pads or truncates a string to n characters.
+func padString(str string, n int64, filler string, leftSide bool) string {
+	runes := []rune(str)
+	if int64(len(runes)) >= n {
+		return substring(runes, 0, n)
+	}
+	padding := []rune(strings.Repeat(filler, int(n)))[:n-int64(len(runes))]
+	if leftSide {
+		return string(padding) + str
+	}
+	return str + string(padding)
+}
+
+func regExMatch(in *Interpreter, args []Value) (Value, error) {
+	regex, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", ToString(args[1])))
+	if err != nil {
+		return nil, err
+	}
+	groups := regex.FindStringSubmatch(ToString(args[0]))
+	if groups == nil {
+		return int64(-1), nil
+	}
+	for idx, name := range args[2:] {
+		if idx+1 < len(groups) {
+			in.Globals[globalName(name)] = groups[idx+1]
+		}
+	}
+	return int64(len(groups) - 1), nil
+}
+
+func regExReplace(in *Interpreter, args []Value) (Value, error) {
+	regex, err := regexp.Compile(ToString(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	return regex.ReplaceAllString(ToString(args[0]), ToString(args[2])), nil
+}
+
+func replace(in *Interpreter, args []Value) (Value, error) {
+	old := ToString(args[1])
+	if old == "" {
+		return ToString(args[0]), nil
+	}
+	return strings.ReplaceAll(ToString(args[0]), old, ToString(args[2])), nil
+}
+
+func split(in *Interpreter, args []Value) (Value, error) {
+	arr := NewArray()
+	for _, part := range strings.Split(ToString(args[0]), ToString(args[1])) {
+		arr.Elems = append(arr.Elems, part)
+	}
+	return arr, nil
+}
+
+func toProper(str string) string {
+	runes := []rune(strings.ToLower(str))
+	start := true
+	for idx, r := range runes {
+		if start && unicode.IsLetter(r) {
+			runes[idx] = unicode.ToUpper(r)
+		}
+		start = !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}
+	return string(runes)
+}
+
+func urlDecode(in *Interpreter, args []Value) (Value, error) {
+	return url.QueryUnescape(ToString(args[0]))
+}
@@ -0,0 +1,564 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"jbextractor/jitterbit/catalog"
+	"jbextractor/jitterbit/script"
+)
+
+// Replacement of a side-effecting function, e.g. RunOperation or DBLookup.
+type MockFunc func(args []Value) (Value, error)
+
+// A call of a mocked function.
+type Call struct {
+	Name string
+	Args []Value
+}
+
+// Script failure with its source position.
+type RuntimeError struct {
+	Pos script.Pos
+	Msg string
+	// Raised by RaiseError.
+	Raised bool
+}
+
+// Error returns the error in line:col: message format.
+func (err *RuntimeError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Pos, err.Msg)
+}
+
+// Default iteration limit of While loops.
+const MAX_ITERATIONS = 50000
+
+// Evaluates a subset of Jitterbit Script: expressions, control functions, global variables
+// and string, date, math and dictionary built-ins. Side-effecting functions must be mocked.
+type Interpreter struct {
+	// Global variables by name without the $ sign.
+	Globals map[string]Value
+	// Messages written by WriteToOperationLog.
+	Log []string
+	// Calls of mocked functions in execution order.
+	Calls []Call
+	// Current time of Now, defaults to the system clock.
+	Now func() time.Time
+	// Returns the source of a script referenced by RunScript, e.g. <TAG>Scripts/Folder/Name</TAG>.
+	Load      func(ref string) (string, error)
+	mocks     map[string]MockFunc
+	lastError string
+	depth     int
+}
+
+// Local variables and arguments of a running script.
+type frame struct {
+	locals map[string]Value
+	args   []Value
+}
+
+// New creates an interpreter without globals or mocks.
+func New() *Interpreter {
+	return &Interpreter{
+		Globals: make(map[string]Value),
+		Now:     time.Now,
+		mocks:   make(map[string]MockFunc),
+	}
+}
+
+// Mock replaces a function, mocks take precedence over built-in implementations.
+func (in *Interpreter) Mock(name string, fn MockFunc) {
+	in.mocks[name] = fn
+}
+
+// Run executes a script and returns the value of its last statement.
+func (in *Interpreter) Run(src string, args ...Value) (Value, error) {
+	parsed, errs := script.Parse(src)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	in.depth++
+	defer func() { in.depth-- }()
+	if in.depth > 100 {
+		return nil, errors.New("RunScript nesting is too deep")
+	}
+
+	fr := &frame{locals: make(map[string]Value), args: args}
+	var result Value
+	for _, block := range parsed.Blocks {
+		value, err := in.statements(fr, block.Body)
+		if err != nil {
+			return nil, err
+		}
+		result = value
+	}
+	return result, nil
+}
+
+// statements evaluates expressions in order and returns the last value.
+func (in *Interpreter) statements(fr *frame, stmts []script.Expr) (Value, error) {
+	var result Value
+	for _, stmt := range stmts {
+		value, err := in.eval(fr, stmt)
+		if err != nil {
+			return nil, err
+		}
+		result = value
+	}
+	return result, nil
+}
+
+// fail creates a runtime error at the node.
+func fail(node script.Node, format string, args ...interface{}) error {
+	return &RuntimeError{Pos: node.Pos(), Msg: fmt.Sprintf(format, args...)}
+}
+
+// eval evaluates an expression.
+func (in *Interpreter) eval(fr *frame, expr script.Expr) (Value, error) {
+	switch e := expr.(type) {
+	case *script.StringLit:
+		return e.Value, nil
+	case *script.NumberLit:
+		if number, err := strconv.ParseInt(e.Raw, 10, 64); err == nil {
+			return number, nil
+		}
+		number, err := strconv.ParseFloat(e.Raw, 64)
+		if err != nil {
+			return nil, fail(e, "invalid number %s", e.Raw)
+		}
+		return number, nil
+	case *script.BoolLit:
+		return e.Value, nil
+	case *script.Ident:
+		return fr.locals[e.Name], nil
+	case *script.GlobalVar:
+		return in.Globals[e.Name], nil
+	case *script.Paren:
+		return in.eval(fr, e.X)
+	case *script.Sequence:
+		return in.statements(fr, e.Exprs)
+	case *script.Array:
+		arr := NewArray()
+		for _, elem := range e.Elems {
+			value, err := in.eval(fr, elem)
+			if err != nil {
+				return nil, err
+			}
+			arr.Elems = append(arr.Elems, value)
+		}
+		return arr, nil
+	case *script.Index:
+		return in.index(fr, e)
+	case *script.Unary:
+		return in.unary(fr, e)
+	case *script.Binary:
+		return in.binary(fr, e)
+	case *script.Assign:
+		return in.assign(fr, e)
+	case *script.IncDec:
+		return in.incDec(fr, e)
+	case *script.Call:
+		return in.call(fr, e)
+	}
+	return nil, fail(expr, "unsupported expression")
+}
+
+// index reads an array element or a dictionary entry, missing entries are null.
+func (in *Interpreter) index(fr *frame, e *script.Index) (Value, error) {
+	container, err := in.eval(fr, e.X)
+	if err != nil {
+		return nil, err
+	}
+	key, err := in.eval(fr, e.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := container.(type) {
+	case *Array:
+		idx, err := ToInt(key)
+		if err != nil {
+			return nil, fail(e.Index, err.Error())
+		}
+		if idx < 0 || idx >= int64(len(c.Elems)) {
+			return nil, nil
+		}
+		return c.Elems[idx], nil
+	case *Dict:
+		return c.Entries[ToString(key)], nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fail(e, "cannot index %s", typeName(container))
+}
+
+// unary evaluates negation and logical not.
+func (in *Interpreter) unary(fr *frame, e *script.Unary) (Value, error) {
+	value, err := in.eval(fr, e.X)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.Op {
+	case script.NOT:
+		return !ToBool(value), nil
+	case script.SUB:
+		if number, ok := value.(int64); ok {
+			return -number, nil
+		}
+		number, err := ToFloat(value)
+		if err != nil {
+			return nil, fail(e, err.Error())
+		}
+		return -number, nil
+	case script.ADD:
+		if isNumber(value) {
+			return value, nil
+		}
+		number, err := ToFloat(value)
+		if err != nil {
+			return nil, fail(e, err.Error())
+		}
+		return normalizeNumber(number), nil
+	}
+	return nil, fail(e, "unsupported operator %s", e.Op)
+}
+
+// binary evaluates arithmetic, comparison and short-circuit logical operators.
+func (in *Interpreter) binary(fr *frame, e *script.Binary) (Value, error) {
+	x, err := in.eval(fr, e.X)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.Op {
+	case script.AND:
+		if !ToBool(x) {
+			return false, nil
+		}
+		y, err := in.eval(fr, e.Y)
+		if err != nil {
+			return nil, err
+		}
+		return ToBool(y), nil
+	case script.OR:
+		if ToBool(x) {
+			return true, nil
+		}
+		y, err := in.eval(fr, e.Y)
+		if err != nil {
+			return nil, err
+		}
+		return ToBool(y), nil
+	}
+
+	y, err := in.eval(fr, e.Y)
+	if err != nil {
+		return nil, err
+	}
+	value, err := operate(e.Op, x, y)
+	if err != nil {
+		return nil, fail(e, err.Error())
+	}
+	return value, nil
+}
+
+// operate applies a binary operator to evaluated operands.
+func operate(op script.TokenKind, x Value, y Value) (Value, error) {
+	switch op {
+	case script.EQ:
+		return Compare(x, y) == 0, nil
+	case script.NEQ:
+		return Compare(x, y) != 0, nil
+	case script.LT:
+		return Compare(x, y) < 0, nil
+	case script.GT:
+		return Compare(x, y) > 0, nil
+	case script.LEQ:
+		return Compare(x, y) <= 0, nil
+	case script.GEQ:
+		return Compare(x, y) >= 0, nil
+	}
+
+	// string concatenation and array element-wise addition
+	if op == script.ADD {
+		if arr, ok := x.(*Array); ok {
+			result := NewArray()
+			for _, elem := range arr.Elems {
+				value, err := operate(op, elem, y)
+				if err != nil {
+					return nil, err
+				}
+				result.Elems = append(result.Elems, value)
+			}
+			return result, nil
+		}
+		_, xString := x.(string)
+		_, yString := y.(string)
+		if xString || yString {
+			return ToString(x) + ToString(y), nil
+		}
+		if date, ok := x.(time.Time); ok {
+			seconds, err := ToFloat(y)
+			if err != nil {
+				return nil, err
+			}
+			return date.Add(time.Duration(seconds * float64(time.Second))), nil
+		}
+	}
+
+	if op == script.SUB {
+		if date, ok := x.(time.Time); ok {
+			if other, ok := y.(time.Time); ok {
+				return normalizeNumber(date.Sub(other).Seconds()), nil
+			}
+			seconds, err := ToFloat(y)
+			if err != nil {
+				return nil, err
+			}
+			return date.Add(-time.Duration(seconds * float64(time.Second))), nil
+		}
+	}
+
+	a, aInt := x.(int64)
+	b, bInt := y.(int64)
+	if aInt && bInt && op != script.DIV && op != script.POW {
+		switch op {
+		case script.ADD:
+			return a + b, nil
+		case script.SUB:
+			return a - b, nil
+		case script.MUL:
+			return a * b, nil
+		case script.MOD:
+			if b == 0 {
+				return nil, errors.New("division by zero")
+			}
+			return a % b, nil
+		}
+	}
+
+	fx, err := ToFloat(x)
+	if err != nil {
+		return nil, err
+	}
+	fy, err := ToFloat(y)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case script.ADD:
+		return fx + fy, nil
+	case script.SUB:
+		return fx - fy, nil
+	case script.MUL:
+		return fx * fy, nil
+	case script.DIV:
+		if fy == 0 {
+			return nil, errors.New("division by zero")
+		}
+		if aInt && bInt && a%b == 0 {
+			return a / b, nil
+		}
+		return fx / fy, nil
+	case script.MOD:
+		if fy == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return math.Mod(fx, fy), nil
+	case script.POW:
+		return normalizeNumber(math.Pow(fx, fy)), nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", op)
+}
+
+// Binary operators of compound assignments.
+var compoundOps = map[script.TokenKind]script.TokenKind{
+	script.ADD_ASSIGN: script.ADD,
+	script.SUB_ASSIGN: script.SUB,
+	script.MUL_ASSIGN: script.MUL,
+	script.DIV_ASSIGN: script.DIV,
+	script.INC:        script.ADD,
+	script.DEC:        script.SUB,
+}
+
+// assign stores a value in a variable, array element or dictionary entry and returns it.
+func (in *Interpreter) assign(fr *frame, e *script.Assign) (Value, error) {
+	value, err := in.eval(fr, e.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if op, ok := compoundOps[e.Op]; ok {
+		current, err := in.eval(fr, e.Target)
+		if err != nil {
+			return nil, err
+		}
+		value, err = operate(op, current, value)
+		if err != nil {
+			return nil, fail(e, err.Error())
+		}
+	}
+
+	if err := in.store(fr, e.Target, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// incDec increments or decrements a variable, postfix forms return the previous value.
+func (in *Interpreter) incDec(fr *frame, e *script.IncDec) (Value, error) {
+	current, err := in.eval(fr, e.X)
+	if err != nil {
+		return nil, err
+	}
+	value, err := operate(compoundOps[e.Op], current, int64(1))
+	if err != nil {
+		return nil, fail(e, err.Error())
+	}
+	if err := in.store(fr, e.X, value); err != nil {
+		return nil, err
+	}
+
+	if e.Prefix {
+		return value, nil
+	}
+	return current, nil
+}
+
+// store writes to an assignable expression, null containers become arrays or dictionaries.
+func (in *Interpreter) store(fr *frame, target script.Expr, value Value) error {
+	switch t := target.(type) {
+	case *script.Ident:
+		fr.locals[t.Name] = value
+		return nil
+	case *script.GlobalVar:
+		in.Globals[t.Name] = value
+		return nil
+	case *script.Paren:
+		return in.store(fr, t.X, value)
+	case *script.Index:
+		container, err := in.eval(fr, t.X)
+		if err != nil {
+			return err
+		}
+		key, err := in.eval(fr, t.Index)
+		if err != nil {
+			return err
+		}
+
+		if container == nil {
+			if _, ok := key.(string); ok {
+				container = NewDict()
+			} else {
+				container = NewArray()
+			}
+			if err := in.store(fr, t.X, container); err != nil {
+				return err
+			}
+		}
+
+		switch c := container.(type) {
+		case *Array:
+			idx, err := ToInt(key)
+			if err != nil || idx < 0 {
+				return fail(t.Index, "invalid array index %s", ToString(key))
+			}
+			for int64(len(c.Elems)) <= idx {
+				c.Elems = append(c.Elems, nil)
+			}
+			c.Elems[idx] = value
+			return nil
+		case *Dict:
+			c.Entries[ToString(key)] = value
+			return nil
+		}
+		return fail(t, "cannot index %s", typeName(container))
+	}
+	return fail(target, "cannot assign to this expression")
+}
+
+// call evaluates a function call. Mocks are called first, control functions evaluate their arguments lazily.
+func (in *Interpreter) call(fr *frame, e *script.Call) (Value, error) {
+	name := e.Name.Name
+	if mock, ok := in.mocks[name]; ok {
+		args, err := in.evalArgs(fr, e.Args)
+		if err != nil {
+			return nil, err
+		}
+		in.Calls = append(in.Calls, Call{Name: name, Args: args})
+		value, err := mock(args)
+		if err != nil {
+			return nil, in.wrap(e, err)
+		}
+		return value, nil
+	}
+
+	fn, known := catalog.Default().Lookup(name)
+	if known {
+		count := len(e.Args)
+		if count < fn.MinArgs() || (fn.MaxArgs() >= 0 && count > fn.MaxArgs()) {
+			return nil, fail(e, "wrong number of arguments: %s", fn.Signature())
+		}
+	}
+
+	if control, ok := controlBuiltins[name]; ok {
+		return control(in, fr, e)
+	}
+
+	builtin, ok := builtins[name]
+	if !ok {
+		if known {
+			return nil, fail(e, "%s is not supported by the interpreter, it must be mocked", name)
+		}
+		return nil, fail(e, "unknown function %s", name)
+	}
+
+	args, err := in.evalArgs(fr, e.Args)
+	if err != nil {
+		return nil, err
+	}
+	value, err := builtin(in, args)
+	if err != nil {
+		return nil, in.wrap(e, err)
+	}
+	return value, nil
+}
+
+// evalArgs evaluates call arguments in order.
+func (in *Interpreter) evalArgs(fr *frame, exprs []script.Expr) ([]Value, error) {
+	args := []Value{}
+	for _, expr := range exprs {
+		value, err := in.eval(fr, expr)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+	return args, nil
+}
+
+// wrap adds the call position to a function error, errors of nested scripts keep their position.
+func (in *Interpreter) wrap(call *script.Call, err error) error {
+	var runtimeErr *RuntimeError
+	if errors.As(err, &runtimeErr) {
+		return err
+	}
+	return &RuntimeError{Pos: call.Pos(), Msg: fmt.Sprintf("%s: %s", call.Name.Name, err.Error())}
+}
+
+// LastError returns the message of the last error, set by RaiseError, SetLastError or a failed Eval.
+func (in *Interpreter) LastError() string {
+	return in.lastError
+}
+
+// globalName strips the $ sign of variable names passed to Get and Set.
+func globalName(value Value) string {
+	return strings.TrimPrefix(ToString(value), "$")
+}
@@ -0,0 +1,305 @@
+package interp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	jbproj "jbextractor/jitterbit/project"
+)
+
+// Extension of test files.
+const TEST_FILE_EXT = ".jbtest.json"
+
+// Test file format with a list of script test cases.
+type TestFile struct {
+	Tests []TestCase `json:"tests"`
+}
+
+// A script execution with its environment and expected outcome.
+type TestCase struct {
+	Name string `json:"name"`
+	// Script file relative to the extraction directory, e.g. Script/Folder/Name.jb.
+	Script string `json:"script"`
+	// Inline script source, used when no file is given.
+	Source string `json:"source"`
+	// Values of the ArgumentList variables.
+	Args []json.RawMessage `json:"args"`
+	// Initial global variables by name without the $ sign.
+	Globals map[string]json.RawMessage `json:"globals"`
+	// Fixed current time, e.g. 2024-01-31 12:00:00.
+	Now   string              `json:"now"`
+	Mocks map[string]MockSpec `json:"mocks"`
+	// Inline scripts returned by RunScript instead of extracted files, by <TAG> path.
+	Scripts map[string]string `json:"scripts"`
+	Expect  Expectation       `json:"expect"`
+}
+
+// Mocked function behavior.
+type MockSpec struct {
+	// Value returned by every call.
+	Returns json.RawMessage `json:"returns"`
+	// Values returned by consecutive calls, the last one is repeated.
+	Sequence []json.RawMessage `json:"sequence"`
+	// Error message returned by every call.
+	Error string `json:"error"`
+}
+
+// Expected test outcome, omitted fields are not checked.
+type Expectation struct {
+	// Value of the last statement.
+	Result json.RawMessage `json:"result"`
+	// Global variables after the run.
+	Globals map[string]json.RawMessage `json:"globals"`
+	// Substring of the expected error message.
+	Error string `json:"error"`
+	// Arguments of every call of a mocked function.
+	Calls map[string][][]json.RawMessage `json:"calls"`
+	// Messages written by WriteToOperationLog.
+	Log []string `json:"log"`
+}
+
+// Outcome of a test case, Err is nil if it passed.
+type TestResult struct {
+	File string
+	Name string
+	Err  error
+}
+
+// decodeValue converts a JSON value to a script value.
+func decodeValue(data json.RawMessage) (Value, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return fromJSON(raw), nil
+}
+
+// fromJSON converts a decoded JSON value, integral numbers become integers.
+func fromJSON(raw interface{}) Value {
+	switch v := raw.(type) {
+	case json.Number:
+		if number, err := v.Int64(); err == nil {
+			return number
+		}
+		number, _ := v.Float64()
+		return number
+	case []interface{}:
+		arr := NewArray()
+		for _, elem := range v {
+			arr.Elems = append(arr.Elems, fromJSON(elem))
+		}
+		return arr
+	case map[string]interface{}:
+		dict := NewDict()
+		for key, value := range v {
+			dict.Entries[key] = fromJSON(value)
+		}
+		return dict
+	}
+	return raw
+}
+
+// mockFunc creates a mock from its specification.
+func mockFunc(spec MockSpec) (MockFunc, error) {
+	if spec.Error != "" {
+		return func(args []Value) (Value, error) { return nil, errors.New(spec.Error) }, nil
+	}
+
+	values := []Value{}
+	for _, data := range spec.Sequence {
+		value, err := decodeValue(data)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		value, err := decodeValue(spec.Returns)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	next := 0
+	return func(args []Value) (Value, error) {
+		value := values[next]
+		if next < len(values)-1 {
+			next++
+		}
+		return value, nil
+	}, nil
+}
+
+// RunTest executes a test case. Script files and RunScript references are resolved in the extraction directory.
+func RunTest(test *TestCase, root string, sep string) error {
+	in := New()
+	resolver := &jbproj.DirResolver{Root: root, Sep: sep}
+	in.Load = func(ref string) (string, error) {
+		tag := strings.TrimSuffix(strings.TrimPrefix(ref, "<TAG>"), "</TAG>")
+		if src, ok := test.Scripts[tag]; ok {
+			return src, nil
+		}
+		path, ok := resolver.TagPath(tag)
+		if !ok || !strings.HasSuffix(path, ".jb") {
+			return "", fmt.Errorf("script %s could not be found", ref)
+		}
+		data, err := os.ReadFile(path)
+		return string(data), err
+	}
+
+	if test.Now != "" {
+		now, err := ToDate(test.Now)
+		if err != nil {
+			return err
+		}
+		in.Now = func() time.Time { return now }
+	}
+	for name, data := range test.Globals {
+		value, err := decodeValue(data)
+		if err != nil {
+			return fmt.Errorf("global %s: %s", name, err.Error())
+		}
+		in.Globals[strings.TrimPrefix(name, "$")] = value
+	}
+	for name, spec := range test.Mocks {
+		mock, err := mockFunc(spec)
+		if err != nil {
+			return fmt.Errorf("mock %s: %s", name, err.Error())
+		}
+		in.Mock(name, mock)
+	}
+	args := []Value{}
+	for _, data := range test.Args {
+		value, err := decodeValue(data)
+		if err != nil {
+			return fmt.Errorf("argument: %s", err.Error())
+		}
+		args = append(args, value)
+	}
+
+	src := test.Source
+	if test.Script != "" {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(test.Script)))
+		if err != nil {
+			return err
+		}
+		src = string(data)
+	}
+
+	result, runErr := in.Run(src, args...)
+	return check(in, &test.Expect, result, runErr)
+}
+
+// check compares the run outcome with the expectation.
+func check(in *Interpreter, expect *Expectation, result Value, runErr error) error {
+	if expect.Error != "" {
+		if runErr == nil {
+			return fmt.Errorf("expected error containing %q, the script succeeded", expect.Error)
+		}
+		if !strings.Contains(runErr.Error(), expect.Error) {
+			return fmt.Errorf("expected error containing %q, got %q", expect.Error, runErr.Error())
+		}
+	} else if runErr != nil {
+		return runErr
+	}
+
+	if len(expect.Result) > 0 {
+		expected, err := decodeValue(expect.Result)
+		if err != nil {
+			return fmt.Errorf("expected result: %s", err.Error())
+		}
+		if !DeepEqual(expected, result) {
+			return fmt.Errorf("expected result %s, got %s", ToString(expected), ToString(result))
+		}
+	}
+
+	names := []string{}
+	for name := range expect.Globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		expected, err := decodeValue(expect.Globals[name])
+		if err != nil {
+			return fmt.Errorf("expected global %s: %s", name, err.Error())
+		}
+		actual := in.Globals[strings.TrimPrefix(name, "$")]
+		if !DeepEqual(expected, actual) {
+			return fmt.Errorf("expected $%s = %s, got %s", strings.TrimPrefix(name, "$"), ToString(expected), ToString(actual))
+		}
+	}
+
+	for name, calls := range expect.Calls {
+		actual := []Call{}
+		for _, call := range in.Calls {
+			if call.Name == name {
+				actual = append(actual, call)
+			}
+		}
+		if len(actual) != len(calls) {
+			return fmt.Errorf("expected %d call(s) of %s, got %d", len(calls), name, len(actual))
+		}
+		for idx, args := range calls {
+			expected := NewArray()
+			for _, data := range args {
+				value, err := decodeValue(data)
+				if err != nil {
+					return fmt.Errorf("expected call of %s: %s", name, err.Error())
+				}
+				expected.Elems = append(expected.Elems, value)
+			}
+			if got := NewArray(actual[idx].Args...); !DeepEqual(expected, got) {
+				return fmt.Errorf("expected call %d of %s with %s, got %s", idx+1, name, ToString(expected), ToString(got))
+			}
+		}
+	}
+
+	if expect.Log != nil && strings.Join(expect.Log, "\n") != strings.Join(in.Log, "\n") {
+		return fmt.Errorf("expected log %q, got %q", expect.Log, in.Log)
+	}
+	return nil
+}
+
+// RunTests executes all test cases of a test file, or of all test files in a directory.
+func RunTests(path string, root string, sep string) ([]*TestResult, error) {
+	results := []*TestResult{}
+	err := filepath.WalkDir(path, func(file string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (file != path && !strings.HasSuffix(file, TEST_FILE_EXT)) {
+			return nil
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var tests TestFile
+		if err := json.Unmarshal(data, &tests); err != nil {
+			return fmt.Errorf("[RunTests] %s: %s", file, err.Error())
+		}
+		for idx := range tests.Tests {
+			test := &tests.Tests[idx]
+			results = append(results, &TestResult{File: file, Name: test.Name, Err: RunTest(test, root, sep)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,289 @@
+package interp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A script value: nil (null), bool, int64, float64, string, time.Time, *Array or *Dict.
+type Value interface{}
+
+// Mutable array shared by all variables holding it.
+type Array struct {
+	Elems []Value
+}
+
+// Dictionary with string keys, iterated in key order.
+type Dict struct {
+	Entries map[string]Value
+}
+
+// NewArray creates an array of the values.
+func NewArray(elems ...Value) *Array {
+	return &Array{Elems: elems}
+}
+
+// NewDict creates an empty dictionary.
+func NewDict() *Dict {
+	return &Dict{Entries: make(map[string]Value)}
+}
+
+// Keys returns the sorted dictionary keys.
+func (dict *Dict) Keys() []string {
+	keys := []string{}
+	for key := range dict.Entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Date and time layout of string conversions.
+const DATE_LAYOUT = "2006-01-02 15:04:05"
+
+// Layouts accepted when converting strings to dates.
+var dateLayouts = []string{
+	DATE_LAYOUT,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05.000",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006",
+}
+
+// ToString converts a value to its string form, booleans become 1 or 0.
+func ToString(value Value) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(DATE_LAYOUT)
+	case *Array:
+		elems := []string{}
+		for _, elem := range v.Elems {
+			elems = append(elems, ToString(elem))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(elems, ","))
+	case *Dict:
+		entries := []string{}
+		for _, key := range v.Keys() {
+			entries = append(entries, fmt.Sprintf("[%s=>%s]", key, ToString(v.Entries[key])))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(entries, ","))
+	}
+	return fmt.Sprint(value)
+}
+
+// ToBool converts a value to a boolean, strings are true when they are "true", "T" or a non-zero number.
+func ToBool(value Value) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		text := strings.TrimSpace(v)
+		if strings.EqualFold(text, "true") || strings.EqualFold(text, "t") {
+			return true
+		}
+		number, err := strconv.ParseFloat(text, 64)
+		return err == nil && number != 0
+	case *Array:
+		return len(v.Elems) > 0
+	case *Dict:
+		return len(v.Entries) > 0
+	}
+	return true
+}
+
+// ToFloat converts a value to a floating point number.
+func ToFloat(value Value) (float64, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		text := strings.TrimSpace(v)
+		if text == "" {
+			return 0, nil
+		}
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %q to a number", v)
+		}
+		return number, nil
+	case time.Time:
+		return float64(v.Unix()), nil
+	}
+	return 0, fmt.Errorf("cannot convert %s to a number", typeName(value))
+}
+
+// ToInt converts a value to an integer, truncating fractions.
+func ToInt(value Value) (int64, error) {
+	if v, ok := value.(int64); ok {
+		return v, nil
+	}
+	if v, ok := value.(string); ok {
+		if number, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return number, nil
+		}
+	}
+	number, err := ToFloat(value)
+	if err != nil {
+		return 0, err
+	}
+	return int64(number), nil
+}
+
+// ToDate converts a value to a date, numbers are Unix timestamps.
+func ToDate(value Value) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case int64:
+		return time.Unix(v, 0).UTC(), nil
+	case float64:
+		return time.Unix(int64(v), 0).UTC(), nil
+	case string:
+		for _, layout := range dateLayouts {
+			if date, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return date, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot convert %q to a date", v)
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %s to a date", typeName(value))
+}
+
+// isNumber reports whether the value is an integer or a floating point number.
+func isNumber(value Value) bool {
+	switch value.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+// typeName returns the script type name of a value.
+func typeName(value Value) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case time.Time:
+		return "date"
+	case *Array:
+		return "array"
+	case *Dict:
+		return "dictionary"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// normalizeNumber returns an integer for whole floating point numbers within the integer range.
+func normalizeNumber(number float64) Value {
+	if number == math.Trunc(number) && math.Abs(number) < 1e15 {
+		return int64(number)
+	}
+	return number
+}
+
+// Compare orders two values: numbers numerically, dates chronologically and everything else as strings.
+func Compare(a Value, b Value) int {
+	if date, ok := a.(time.Time); ok {
+		if other, err := ToDate(b); err == nil {
+			return date.Compare(other)
+		}
+	}
+	if date, ok := b.(time.Time); ok {
+		if other, err := ToDate(a); err == nil {
+			return other.Compare(date)
+		}
+	}
+
+	if isNumber(a) || isNumber(b) {
+		x, errX := ToFloat(a)
+		y, errY := ToFloat(b)
+		if errX == nil && errY == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(ToString(a), ToString(b))
+}
+
+// DeepEqual compares values recursively, scalars are compared with Compare.
+func DeepEqual(a Value, b Value) bool {
+	switch x := a.(type) {
+	case *Array:
+		y, ok := b.(*Array)
+		if !ok || len(x.Elems) != len(y.Elems) {
+			return false
+		}
+		for idx := range x.Elems {
+			if !DeepEqual(x.Elems[idx], y.Elems[idx]) {
+				return false
+			}
+		}
+		return true
+	case *Dict:
+		y, ok := b.(*Dict)
+		if !ok || len(x.Entries) != len(y.Entries) {
+			return false
+		}
+		for key, value := range x.Entries {
+			other, ok := y.Entries[key]
+			if !ok || !DeepEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return b == nil
+	}
+	switch b.(type) {
+	case *Array, *Dict, nil:
+		return false
+	}
+	return Compare(a, b) == 0
+}
@@ -0,0 +1,420 @@
+package javascript
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"jbextractor/jitterbit/script"
+)
+
+// Token category.
+type TokenKind int
+
+const (
+	ILLEGAL TokenKind = iota
+	EOF
+	// Identifiers including keywords and reserved words.
+	IDENT
+	PUNCT
+	NUMBER
+	STRING
+	TEMPLATE
+	REGEX
+)
+
+// A lexical token, positions are byte based like Jitterbit Script positions.
+type Token struct {
+	Kind TokenKind
+	// Source text of the token.
+	Text string
+	// Decoded value of string literals, the error message of ILLEGAL tokens.
+	Value string
+	Pos   script.Pos
+	End   script.Pos
+	// Whether a line terminator precedes the token, used for automatic semicolon insertion.
+	Newline bool
+}
+
+// Punctuators ordered longest first, including ES6+ operators which are reported by the parser.
+var punctuators = []string{
+	">>>=",
+	"...", "===", "!==", "**=", ">>>", "<<=", ">>=",
+	"=>", "==", "!=", "<=", ">=", "&&", "||", "??", "?.", "++", "--", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<", ">>", "**",
+	"{", "}", "(", ")", "[", "]", ";", ",", "<", ">", "+", "-", "*", "/", "%", "&", "|", "^", "!", "~", "?", ":", "=", ".",
+}
+
+// ES5 JavaScript tokenizer. Regular expression literals are scanned on request of the parser.
+type lexer struct {
+	src    string
+	offset int
+	line   int
+	col    int
+	// Comments scanned so far.
+	comments []*Comment
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+// pos returns the current position.
+func (lex *lexer) pos() script.Pos {
+	return script.Pos{Offset: lex.offset, Line: lex.line, Col: lex.col}
+}
+
+// peek returns the rune at the current offset, 0 past the end.
+func (lex *lexer) peek() (rune, int) {
+	if lex.offset >= len(lex.src) {
+		return 0, 0
+	}
+	return utf8.DecodeRuneInString(lex.src[lex.offset:])
+}
+
+// advance moves past n bytes, tracking lines.
+func (lex *lexer) advance(n int) {
+	for idx := 0; idx < n && lex.offset < len(lex.src); {
+		ch, size := utf8.DecodeRuneInString(lex.src[lex.offset:])
+		lex.offset += size
+		idx += size
+		if ch == '\n' || ch == '\u2028' || ch == '\u2029' || (ch == '\r' && !strings.HasPrefix(lex.src[lex.offset:], "\n")) {
+			lex.line++
+			lex.col = 1
+		} else {
+			lex.col += size
+		}
+	}
+}
+
+// isLineTerminator reports whether the rune ends a line.
+func isLineTerminator(ch rune) bool {
+	return ch == '\n' || ch == '\r' || ch == '\u2028' || ch == '\u2029'
+}
+
+// isIdentStart reports whether the rune can start an identifier.
+func isIdentStart(ch rune) bool {
+	return ch == '$' || ch == '_' || unicode.IsLetter(ch)
+}
+
+// isIdentPart reports whether the rune can continue an identifier.
+func isIdentPart(ch rune) bool {
+	return isIdentStart(ch) || unicode.IsDigit(ch) || unicode.Is(unicode.Mn, ch) || unicode.Is(unicode.Mc, ch) ||
+		unicode.Is(unicode.Pc, ch) || ch == '\u200c' || ch == '\u200d'
+}
+
+// skipSpace skips white space and comments, reporting whether a line terminator was passed.
+func (lex *lexer) skipSpace() (newline bool, err *Token) {
+	for lex.offset < len(lex.src) {
+		ch, size := lex.peek()
+		switch {
+		case isLineTerminator(ch):
+			newline = true
+			lex.advance(size)
+		case ch == ' ' || ch == '\t' || ch == '\v' || ch == '\f' || ch == '\ufeff' || unicode.Is(unicode.Zs, ch):
+			lex.advance(size)
+		case strings.HasPrefix(lex.src[lex.offset:], "//"):
+			start := lex.pos()
+			for lex.offset < len(lex.src) {
+				if ch, _ := lex.peek(); isLineTerminator(ch) {
+					break
+				}
+				lex.advance(1)
+			}
+			lex.comments = append(lex.comments, &Comment{Text: lex.src[start.Offset:lex.offset], Pos: start, End: lex.pos()})
+		case strings.HasPrefix(lex.src[lex.offset:], "/*"):
+			start := lex.pos()
+			end := strings.Index(lex.src[lex.offset+2:], "*/")
+			if end < 0 {
+				lex.advance(len(lex.src) - lex.offset)
+				return newline, &Token{Kind: ILLEGAL, Value: "unterminated comment", Pos: start, End: lex.pos()}
+			}
+			comment := lex.src[lex.offset : lex.offset+end+4]
+			if strings.ContainsAny(comment, "\n\r\u2028\u2029") {
+				newline = true
+			}
+			lex.advance(len(comment))
+			lex.comments = append(lex.comments, &Comment{Text: comment, Pos: start, End: lex.pos()})
+		default:
+			return newline, nil
+		}
+	}
+	return newline, nil
+}
+
+// next scans the next token, a slash is always scanned as division operator.
+func (lex *lexer) next() Token {
+	newline, illegal := lex.skipSpace()
+	if illegal != nil {
+		illegal.Newline = newline
+		return *illegal
+	}
+
+	start := lex.pos()
+	tok := lex.scan()
+	tok.Pos = start
+	tok.End = lex.pos()
+	tok.Text = lex.src[start.Offset:lex.offset]
+	tok.Newline = newline
+	return tok
+}
+
+// scan scans a token starting at the current offset.
+func (lex *lexer) scan() Token {
+	if lex.offset >= len(lex.src) {
+		return Token{Kind: EOF}
+	}
+
+	ch, size := lex.peek()
+	rest := lex.src[lex.offset:]
+	switch {
+	case isIdentStart(ch) || ch == '\\':
+		return lex.scanIdent()
+	case ch >= '0' && ch <= '9' || (ch == '.' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9'):
+		return lex.scanNumber()
+	case ch == '"' || ch == '\'':
+		return lex.scanString(byte(ch))
+	case ch == '`':
+		if msg := lex.scanTemplate(); msg != "" {
+			return Token{Kind: ILLEGAL, Value: msg}
+		}
+		return Token{Kind: TEMPLATE}
+	}
+
+	for _, punct := range punctuators {
+		if strings.HasPrefix(rest, punct) {
+			// ?. followed by a digit is a conditional operator and a number
+			if punct == "?." && len(rest) > 2 && rest[2] >= '0' && rest[2] <= '9' {
+				continue
+			}
+			lex.advance(len(punct))
+			return Token{Kind: PUNCT}
+		}
+	}
+
+	lex.advance(size)
+	return Token{Kind: ILLEGAL, Value: "unexpected character " + strconv.QuoteRune(ch)}
+}
+
+// scanIdent scans an identifier name with optional \uXXXX escapes.
+func (lex *lexer) scanIdent() Token {
+	for lex.offset < len(lex.src) {
+		ch, size := lex.peek()
+		if ch == '\\' {
+			if !strings.HasPrefix(lex.src[lex.offset:], "\\u") {
+				return Token{Kind: ILLEGAL, Value: "invalid escape in identifier"}
+			}
+			lex.advance(2)
+			if lex.offset+4 > len(lex.src) {
+				return Token{Kind: ILLEGAL, Value: "invalid escape in identifier"}
+			}
+			if _, err := strconv.ParseUint(lex.src[lex.offset:lex.offset+4], 16, 16); err != nil {
+				return Token{Kind: ILLEGAL, Value: "invalid escape in identifier"}
+			}
+			lex.advance(4)
+			continue
+		}
+		if !isIdentPart(ch) {
+			break
+		}
+		lex.advance(size)
+	}
+	return Token{Kind: IDENT}
+}
+
+// scanNumber scans decimal, hexadecimal, legacy octal and ES6 binary and octal literals.
+func (lex *lexer) scanNumber() Token {
+	rest := lex.src[lex.offset:]
+	digits := func(valid func(byte) bool) int {
+		count := 0
+		for lex.offset < len(lex.src) && valid(lex.src[lex.offset]) {
+			lex.advance(1)
+			count++
+		}
+		return count
+	}
+	decimal := func(ch byte) bool { return ch >= '0' && ch <= '9' }
+
+	if len(rest) > 1 && rest[0] == '0' && strings.ContainsRune("xXbBoO", rune(rest[1])) {
+		lex.advance(2)
+		valid := func(ch byte) bool { return strings.IndexByte("0123456789abcdefABCDEF", ch) >= 0 }
+		switch rest[1] {
+		case 'b', 'B':
+			valid = func(ch byte) bool { return ch == '0' || ch == '1' }
+		case 'o', 'O':
+			valid = func(ch byte) bool { return ch >= '0' && ch <= '7' }
+		}
+		if digits(valid) == 0 {
+			return Token{Kind: ILLEGAL, Value: "missing digits in number literal"}
+		}
+	} else {
+		digits(decimal)
+		if lex.offset < len(lex.src) && lex.src[lex.offset] == '.' {
+			lex.advance(1)
+			digits(decimal)
+		}
+		if lex.offset < len(lex.src) && (lex.src[lex.offset] == 'e' || lex.src[lex.offset] == 'E') {
+			lex.advance(1)
+			if lex.offset < len(lex.src) && (lex.src[lex.offset] == '+' || lex.src[lex.offset] == '-') {
+				lex.advance(1)
+			}
+			if digits(decimal) == 0 {
+				return Token{Kind: ILLEGAL, Value: "missing exponent in number literal"}
+			}
+		}
+	}
+
+	if ch, _ := lex.peek(); isIdentStart(ch) || (ch >= '0' && ch <= '9') {
+		return Token{Kind: ILLEGAL, Value: "identifier starts immediately after number literal"}
+	}
+	return Token{Kind: NUMBER}
+}
+
+// scanString scans a single or double quoted string literal and decodes its value.
+func (lex *lexer) scanString(quote byte) Token {
+	lex.advance(1)
+	var value strings.Builder
+	for {
+		if lex.offset >= len(lex.src) {
+			return Token{Kind: ILLEGAL, Value: "unterminated string literal"}
+		}
+		ch, size := lex.peek()
+		switch {
+		case ch == rune(quote):
+			lex.advance(1)
+			return Token{Kind: STRING, Value: value.String()}
+		case ch == '\n' || ch == '\r':
+			return Token{Kind: ILLEGAL, Value: "unterminated string literal"}
+		case ch == '\\':
+			lex.advance(1)
+			lex.scanEscape(&value)
+		default:
+			value.WriteRune(ch)
+			lex.advance(size)
+		}
+	}
+}
+
+// Single character escape sequences.
+var escapes = map[rune]string{'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v", '0': "\x00"}
+
+// scanEscape decodes the escape sequence after a backslash.
+func (lex *lexer) scanEscape(value *strings.Builder) {
+	ch, size := lex.peek()
+	if ch == 0 && size == 0 {
+		return
+	}
+	rest := lex.src[lex.offset:]
+	hex := func(count int) bool {
+		if len(rest) <= count {
+			return false
+		}
+		code, err := strconv.ParseUint(rest[1:1+count], 16, 32)
+		if err != nil {
+			return false
+		}
+		value.WriteRune(rune(code))
+		lex.advance(1 + count)
+		return true
+	}
+
+	switch {
+	case ch == 'x' && hex(2):
+	case ch == 'u' && hex(4):
+	case isLineTerminator(ch):
+		// line continuation
+		lex.advance(size)
+		if ch == '\r' && strings.HasPrefix(lex.src[lex.offset:], "\n") {
+			lex.advance(1)
+		}
+	default:
+		if escaped, ok := escapes[ch]; ok && !(ch == '0' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9') {
+			value.WriteString(escaped)
+		} else {
+			value.WriteRune(ch)
+		}
+		lex.advance(size)
+	}
+}
+
+// scanTemplate skips an ES6 template literal including nested substitutions, returning an error message.
+func (lex *lexer) scanTemplate() string {
+	lex.advance(1)
+	for lex.offset < len(lex.src) {
+		switch {
+		case lex.src[lex.offset] == '\\':
+			lex.advance(2)
+		case lex.src[lex.offset] == '`':
+			lex.advance(1)
+			return ""
+		case strings.HasPrefix(lex.src[lex.offset:], "${"):
+			lex.advance(2)
+			if msg := lex.skipSubstitution(); msg != "" {
+				return msg
+			}
+		default:
+			lex.advance(1)
+		}
+	}
+	return "unterminated template literal"
+}
+
+// skipSubstitution skips the expression of a template substitution up to its closing brace.
+func (lex *lexer) skipSubstitution() string {
+	depth := 0
+	for {
+		if _, illegal := lex.skipSpace(); illegal != nil {
+			return illegal.Value
+		}
+		if lex.offset >= len(lex.src) {
+			return "unterminated template literal"
+		}
+		switch lex.src[lex.offset] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				lex.advance(1)
+				return ""
+			}
+			depth--
+		}
+		if tok := lex.scan(); tok.Kind == ILLEGAL {
+			return tok.Value
+		}
+	}
+}
+
+// scanRegex rescans a division token as regular expression literal.
+func (lex *lexer) scanRegex(tok Token) Token {
+	lex.offset, lex.line, lex.col = tok.Pos.Offset, tok.Pos.Line, tok.Pos.Col
+	lex.advance(1)
+
+	inClass := false
+	for {
+		ch, size := lex.peek()
+		if size == 0 || isLineTerminator(ch) {
+			return Token{Kind: ILLEGAL, Value: "unterminated regular expression literal", Pos: tok.Pos, End: lex.pos(), Newline: tok.Newline}
+		}
+		lex.advance(size)
+		switch {
+		case ch == '\\':
+			if next, size := lex.peek(); size > 0 && !isLineTerminator(next) {
+				lex.advance(size)
+			}
+		case ch == '[':
+			inClass = true
+		case ch == ']':
+			inClass = false
+		case ch == '/' && !inClass:
+			for {
+				ch, size := lex.peek()
+				if size == 0 || !isIdentPart(ch) {
+					break
+				}
+				lex.advance(size)
+			}
+			return Token{Kind: REGEX, Text: lex.src[tok.Pos.Offset:lex.offset], Pos: tok.Pos, End: lex.pos(), Newline: tok.Newline}
+		}
+	}
+}
@@ -0,0 +1,1035 @@
+package javascript
+
+import (
+	"fmt"
+	"strings"
+
+	"jbextractor/jitterbit/script"
+)
+
+// A string literal in the source.
+type StringLiteral struct {
+	// Source text including quotes.
+	Raw   string
+	Value string
+	Pos   script.Pos
+	End   script.Pos
+}
+
+// A function call with a plain or dotted callee name, e.g. Jitterbit.RunOperation("op.<id>").
+type Call struct {
+	// Dotted callee name, empty for computed callees.
+	Callee string
+	Pos    script.Pos
+	// Argument positions.
+	Args []script.Pos
+	// String literal arguments, nil for other argument expressions.
+	Literals []*StringLiteral
+}
+
+// Use of a language feature which is not part of ES5.
+type Feature struct {
+	Pos  script.Pos
+	Name string
+}
+
+// A line or block comment including its delimiters.
+type Comment struct {
+	Text string
+	Pos  script.Pos
+	End  script.Pos
+}
+
+// Checked JavaScript source with the calls, string literals, comments and unsupported features
+// found before the first syntax error.
+type Program struct {
+	Calls []*Call
+	// String literals in expressions, property names are not included.
+	Strings  []*StringLiteral
+	Comments []*Comment
+	Features []*Feature
+}
+
+// Reserved words of ES5, which cannot be used as identifiers.
+var reserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "continue": true, "debugger": true, "default": true, "delete": true,
+	"do": true, "else": true, "finally": true, "for": true, "function": true, "if": true, "in": true, "instanceof": true,
+	"new": true, "return": true, "switch": true, "this": true, "throw": true, "try": true, "typeof": true, "var": true,
+	"void": true, "while": true, "with": true, "class": true, "const": true, "enum": true, "export": true,
+	"extends": true, "import": true, "super": true, "null": true, "true": true, "false": true,
+}
+
+// Binary operator precedence, higher binds tighter.
+var precedence = map[string]int{
+	"??": 1, "||": 1, "&&": 2, "|": 3, "^": 4, "&": 5,
+	"==": 6, "!=": 6, "===": 6, "!==": 6,
+	"<": 7, ">": 7, "<=": 7, ">=": 7, "instanceof": 7, "in": 7,
+	"<<": 8, ">>": 8, ">>>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+	"**": 11,
+}
+
+// Assignment operators.
+var assignOps = map[string]bool{
+	"=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true, "<<=": true, ">>=": true, ">>>=": true,
+	"&=": true, "|=": true, "^=": true, "**=": true,
+}
+
+// Category of a parsed expression, used for arrow functions, assignment targets and call arguments.
+type exprKind int
+
+const (
+	exprOther exprKind = iota
+	exprIdent
+	exprMember
+	exprParen
+	exprString
+	exprPattern
+)
+
+// Parsed expression summary.
+type expr struct {
+	kind exprKind
+	// Dotted name of identifiers and member chains.
+	name string
+	str  *StringLiteral
+}
+
+// Stops parsing at the first syntax error.
+type bailout struct{}
+
+// Recursive descent ES5 parser which records calls and ES6+ features instead of building a syntax tree.
+type parser struct {
+	lex  *lexer
+	tok  Token
+	prog *Program
+	err  *script.Error
+}
+
+// Parse checks the ES5 syntax of a script. Parsing stops at the first syntax error.
+func Parse(src string) (*Program, []*script.Error) {
+	p := &parser{lex: newLexer(src), prog: &Program{Calls: []*Call{}, Strings: []*StringLiteral{}, Features: []*Feature{}}}
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				if _, ok := r.(bailout); !ok {
+					panic(r)
+				}
+			}
+		}()
+		p.next()
+		for p.tok.Kind != EOF {
+			p.parseStatement()
+		}
+	}()
+
+	p.prog.Comments = append([]*Comment{}, p.lex.comments...)
+	if p.err != nil {
+		return p.prog, []*script.Error{p.err}
+	}
+	return p.prog, nil
+}
+
+// next advances to the next token.
+func (p *parser) next() {
+	p.tok = p.lex.next()
+	if p.tok.Kind == ILLEGAL {
+		p.fail(p.tok.Pos, p.tok.Value)
+	}
+}
+
+// fail records a syntax error and stops parsing.
+func (p *parser) fail(pos script.Pos, msg string) {
+	p.err = &script.Error{Pos: pos, Msg: msg}
+	panic(bailout{})
+}
+
+// unexpected fails at the current token.
+func (p *parser) unexpected() {
+	if p.tok.Kind == EOF {
+		p.fail(p.tok.Pos, "unexpected end of script")
+	}
+	p.fail(p.tok.Pos, fmt.Sprintf("unexpected token %s", p.tok.Text))
+}
+
+// feature records the use of an unsupported language feature.
+func (p *parser) feature(pos script.Pos, name string) {
+	p.prog.Features = append(p.prog.Features, &Feature{Pos: pos, Name: name})
+}
+
+// is reports whether the current token is the punctuator or keyword.
+func (p *parser) is(text string) bool {
+	return (p.tok.Kind == PUNCT || p.tok.Kind == IDENT) && p.tok.Text == text
+}
+
+// accept consumes the token if it matches.
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// expect consumes the token or fails.
+func (p *parser) expect(text string) {
+	if !p.is(text) {
+		if p.tok.Kind == EOF {
+			p.fail(p.tok.Pos, fmt.Sprintf("missing %s before end of script", text))
+		}
+		p.fail(p.tok.Pos, fmt.Sprintf("missing %s before %s", text, p.tok.Text))
+	}
+	p.next()
+}
+
+// peek returns the token after the current one without consuming it.
+func (p *parser) peek() Token {
+	saved := *p.lex
+	tok := p.lex.next()
+	*p.lex = saved
+	return tok
+}
+
+// isIdent reports whether the current token is an identifier which is not a reserved word.
+func (p *parser) isIdent() bool {
+	return p.tok.Kind == IDENT && !reserved[p.tok.Text]
+}
+
+// parseIdent consumes an identifier.
+func (p *parser) parseIdent() string {
+	if !p.isIdent() {
+		p.unexpected()
+	}
+	name := p.tok.Text
+	p.next()
+	return name
+}
+
+// semicolon consumes a statement terminator, applying automatic semicolon insertion.
+func (p *parser) semicolon() {
+	if p.accept(";") {
+		return
+	}
+	if p.is("}") || p.tok.Kind == EOF || p.tok.Newline {
+		return
+	}
+	p.fail(p.tok.Pos, fmt.Sprintf("missing ; before %s", p.tok.Text))
+}
+
+// parseStatement parses a statement or function declaration.
+func (p *parser) parseStatement() {
+	tok := p.tok
+	if tok.Kind == PUNCT {
+		switch tok.Text {
+		case "{":
+			p.parseBlock()
+			return
+		case ";":
+			p.next()
+			return
+		}
+	}
+	if tok.Kind != IDENT {
+		p.parseExpression(false)
+		p.semicolon()
+		return
+	}
+
+	switch tok.Text {
+	case "var":
+		p.next()
+		p.parseDeclarations(false)
+		p.semicolon()
+	case "const":
+		p.feature(tok.Pos, "const declaration")
+		p.next()
+		p.parseDeclarations(false)
+		p.semicolon()
+	case "let":
+		if next := p.peek(); next.Kind == IDENT || next.Text == "[" || next.Text == "{" {
+			p.feature(tok.Pos, "let declaration")
+			p.next()
+			p.parseDeclarations(false)
+			p.semicolon()
+			return
+		}
+		p.parseExpression(false)
+		p.semicolon()
+	case "if":
+		p.next()
+		p.parseCondition()
+		p.parseStatement()
+		if p.accept("else") {
+			p.parseStatement()
+		}
+	case "do":
+		p.next()
+		p.parseStatement()
+		p.expect("while")
+		p.parseCondition()
+		p.accept(";")
+	case "while", "with":
+		p.next()
+		p.parseCondition()
+		p.parseStatement()
+	case "for":
+		p.parseFor()
+	case "continue", "break":
+		p.next()
+		if p.isIdent() && !p.tok.Newline {
+			p.next()
+		}
+		p.semicolon()
+	case "return":
+		p.next()
+		if !p.is(";") && !p.is("}") && p.tok.Kind != EOF && !p.tok.Newline {
+			p.parseExpression(false)
+		}
+		p.semicolon()
+	case "throw":
+		p.next()
+		if p.tok.Newline {
+			p.fail(p.tok.Pos, "line break after throw")
+		}
+		p.parseExpression(false)
+		p.semicolon()
+	case "switch":
+		p.parseSwitch()
+	case "try":
+		p.parseTry()
+	case "debugger":
+		p.next()
+		p.semicolon()
+	case "function":
+		p.parseFunction(true)
+	case "class":
+		p.parseClass(true)
+	case "import", "export":
+		p.parseModule()
+	case "async":
+		if next := p.peek(); next.Text == "function" && !next.Newline {
+			p.feature(tok.Pos, "async function")
+			p.next()
+			p.parseFunction(true)
+			return
+		}
+		p.parseExpression(false)
+		p.semicolon()
+	default:
+		if p.isIdent() && p.peek().Text == ":" {
+			// labelled statement
+			p.next()
+			p.next()
+			p.parseStatement()
+			return
+		}
+		p.parseExpression(false)
+		p.semicolon()
+	}
+}
+
+// parseBlock parses statements in braces.
+func (p *parser) parseBlock() {
+	p.expect("{")
+	for !p.is("}") {
+		if p.tok.Kind == EOF {
+			p.fail(p.tok.Pos, "missing } before end of script")
+		}
+		p.parseStatement()
+	}
+	p.next()
+}
+
+// parseCondition parses a parenthesized expression.
+func (p *parser) parseCondition() {
+	p.expect("(")
+	p.parseExpression(false)
+	p.expect(")")
+}
+
+// parseDeclarations parses a comma separated variable declaration list.
+func (p *parser) parseDeclarations(noIn bool) {
+	for {
+		p.parseBinding()
+		if p.accept("=") {
+			p.parseAssign(noIn)
+		}
+		if !p.accept(",") {
+			return
+		}
+	}
+}
+
+// parseBinding parses a declared name or an ES6 destructuring pattern.
+func (p *parser) parseBinding() {
+	if p.is("[") || p.is("{") {
+		p.feature(p.tok.Pos, "destructuring")
+		p.parsePrimary()
+		return
+	}
+	p.parseIdent()
+}
+
+// parseFor parses for, for-in and ES6 for-of loops.
+func (p *parser) parseFor() {
+	p.next()
+	p.expect("(")
+
+	if !p.is(";") {
+		switch {
+		case p.is("var"):
+			p.next()
+			p.parseDeclarations(true)
+		case p.is("const") || (p.is("let") && p.peek().Kind == IDENT) || (p.is("let") && (p.peek().Text == "[" || p.peek().Text == "{")):
+			p.feature(p.tok.Pos, fmt.Sprintf("%s declaration", p.tok.Text))
+			p.next()
+			p.parseDeclarations(true)
+		default:
+			p.parseExpression(true)
+		}
+
+		switch {
+		case p.accept("in"):
+			p.parseExpression(false)
+			p.expect(")")
+			p.parseStatement()
+			return
+		case p.is("of"):
+			p.feature(p.tok.Pos, "for...of loop")
+			p.next()
+			p.parseAssign(false)
+			p.expect(")")
+			p.parseStatement()
+			return
+		}
+	}
+
+	p.expect(";")
+	if !p.is(";") {
+		p.parseExpression(false)
+	}
+	p.expect(";")
+	if !p.is(")") {
+		p.parseExpression(false)
+	}
+	p.expect(")")
+	p.parseStatement()
+}
+
+// parseSwitch parses a switch statement.
+func (p *parser) parseSwitch() {
+	p.next()
+	p.parseCondition()
+	p.expect("{")
+	hasDefault := false
+	for !p.accept("}") {
+		switch {
+		case p.accept("case"):
+			p.parseExpression(false)
+		case p.is("default"):
+			if hasDefault {
+				p.fail(p.tok.Pos, "more than one switch default")
+			}
+			hasDefault = true
+			p.next()
+		default:
+			p.unexpected()
+		}
+		p.expect(":")
+		for !p.is("case") && !p.is("default") && !p.is("}") {
+			if p.tok.Kind == EOF {
+				p.fail(p.tok.Pos, "missing } before end of script")
+			}
+			p.parseStatement()
+		}
+	}
+}
+
+// parseTry parses a try statement with catch and/or finally blocks.
+func (p *parser) parseTry() {
+	p.next()
+	p.parseBlock()
+	handled := false
+	if p.is("catch") {
+		handled = true
+		p.next()
+		if p.is("{") {
+			p.feature(p.tok.Pos, "optional catch binding")
+		} else {
+			p.expect("(")
+			p.parseBinding()
+			p.expect(")")
+		}
+		p.parseBlock()
+	}
+	if p.accept("finally") {
+		handled = true
+		p.parseBlock()
+	}
+	if !handled {
+		p.fail(p.tok.Pos, "missing catch or finally after try")
+	}
+}
+
+// parseModule skips an ES6 import or export statement.
+func (p *parser) parseModule() {
+	tok := p.tok
+	p.feature(tok.Pos, fmt.Sprintf("%s statement", tok.Text))
+	p.next()
+	if tok.Text == "export" {
+		switch {
+		case p.accept("default"):
+			p.parseAssign(false)
+			p.semicolon()
+			return
+		case p.is("var") || p.is("let") || p.is("const") || p.is("function") || p.is("class") || p.is("async"):
+			p.parseStatement()
+			return
+		}
+	}
+
+	// import and export lists up to the module name or the end of the statement
+	depth := 0
+	for p.tok.Kind != EOF {
+		switch {
+		case p.is("{"):
+			depth++
+		case p.is("}"):
+			depth--
+		case depth == 0 && p.tok.Kind == STRING:
+			p.next()
+			p.semicolon()
+			return
+		case depth == 0 && p.is(";"):
+			p.next()
+			return
+		}
+		p.next()
+	}
+}
+
+// parseFunction parses a function declaration or expression after the function keyword.
+func (p *parser) parseFunction(declaration bool) {
+	p.expect("function")
+	if p.is("*") {
+		p.feature(p.tok.Pos, "generator function")
+		p.next()
+	}
+	if declaration || p.isIdent() {
+		p.parseIdent()
+	}
+	p.parseParams()
+	p.parseBlock()
+}
+
+// parseParams parses a parenthesized parameter list.
+func (p *parser) parseParams() {
+	p.expect("(")
+	for !p.accept(")") {
+		if p.is("...") {
+			p.feature(p.tok.Pos, "rest parameter")
+			p.next()
+		}
+		p.parseBinding()
+		if p.is("=") {
+			p.feature(p.tok.Pos, "default parameter")
+			p.next()
+			p.parseAssign(false)
+		}
+		if !p.is(")") {
+			p.expect(",")
+			if p.is(")") {
+				p.feature(p.tok.Pos, "trailing comma in parameters")
+			}
+		}
+	}
+}
+
+// parseClass parses an ES6 class declaration or expression.
+func (p *parser) parseClass(declaration bool) {
+	p.feature(p.tok.Pos, "class")
+	p.expect("class")
+	if declaration || p.isIdent() {
+		p.parseIdent()
+	}
+	if p.accept("extends") {
+		p.parseLeftHandSide()
+	}
+	p.expect("{")
+	for !p.accept("}") {
+		if p.accept(";") {
+			continue
+		}
+		if p.is("static") && p.peek().Text != "(" {
+			p.next()
+		}
+		p.parseMethod()
+	}
+}
+
+// parseMethod parses a class method or an object literal method after its modifiers.
+func (p *parser) parseMethod() {
+	if (p.is("get") || p.is("set") || p.is("async")) && p.peek().Text != "(" {
+		p.next()
+	}
+	if p.is("*") {
+		p.feature(p.tok.Pos, "generator function")
+		p.next()
+	}
+	p.parsePropertyName()
+	p.parseParams()
+	p.parseBlock()
+}
+
+// parsePropertyName parses an identifier name, string, number or ES6 computed property name.
+func (p *parser) parsePropertyName() {
+	switch p.tok.Kind {
+	case IDENT, STRING, NUMBER:
+		p.next()
+	default:
+		if !p.is("[") {
+			p.unexpected()
+		}
+		p.feature(p.tok.Pos, "computed property name")
+		p.next()
+		p.parseAssign(false)
+		p.expect("]")
+	}
+}
+
+// parseExpression parses a comma separated expression list. noIn excludes the in operator in for loop heads.
+func (p *parser) parseExpression(noIn bool) *expr {
+	x := p.parseAssign(noIn)
+	for p.accept(",") {
+		p.parseAssign(noIn)
+		x = &expr{}
+	}
+	return x
+}
+
+// parseAssign parses an assignment, conditional or arrow function expression.
+func (p *parser) parseAssign(noIn bool) *expr {
+	start := p.tok
+	if next := p.peek(); p.is("async") && !next.Newline && next.Kind == IDENT && !reserved[next.Text] {
+		// async arrow function with a single parameter
+		p.feature(start.Pos, "async function")
+		p.next()
+	}
+	if p.is("yield") && p.peek().Kind != PUNCT {
+		p.feature(start.Pos, "yield expression")
+		p.next()
+	}
+
+	x := p.parseConditional(noIn)
+	if p.is("=>") {
+		if x.kind != exprIdent && x.kind != exprParen || strings.Contains(x.name, ".") || p.tok.Newline {
+			p.unexpected()
+		}
+		p.feature(start.Pos, "arrow function")
+		p.next()
+		if p.is("{") {
+			p.parseBlock()
+		} else {
+			p.parseAssign(noIn)
+		}
+		return &expr{}
+	}
+
+	if p.tok.Kind == PUNCT && assignOps[p.tok.Text] {
+		switch {
+		case x.kind == exprIdent || x.kind == exprMember:
+		case x.kind == exprPattern && p.tok.Text == "=":
+			p.feature(start.Pos, "destructuring")
+		default:
+			p.fail(p.tok.Pos, "invalid assignment target")
+		}
+		if p.tok.Text == "**=" {
+			p.feature(p.tok.Pos, "exponentiation operator")
+		}
+		p.next()
+		p.parseAssign(noIn)
+		return &expr{}
+	}
+	return x
+}
+
+// parseConditional parses a conditional expression.
+func (p *parser) parseConditional(noIn bool) *expr {
+	x := p.parseBinary(1, noIn)
+	if !p.accept("?") {
+		return x
+	}
+	p.parseAssign(false)
+	p.expect(":")
+	p.parseAssign(noIn)
+	return &expr{}
+}
+
+// parseBinary parses binary operators with at least the minimum precedence.
+func (p *parser) parseBinary(min int, noIn bool) *expr {
+	x := p.parseUnary()
+	for {
+		prec, ok := precedence[p.tok.Text]
+		if !ok || prec < min || (p.tok.Kind != PUNCT && p.tok.Kind != IDENT) || (noIn && p.tok.Text == "in") {
+			return x
+		}
+		switch p.tok.Text {
+		case "**":
+			p.feature(p.tok.Pos, "exponentiation operator")
+		case "??":
+			p.feature(p.tok.Pos, "nullish coalescing operator")
+		}
+
+		op := p.tok.Text
+		p.next()
+		if op == "**" {
+			// right associative
+			p.parseBinary(prec, noIn)
+		} else {
+			p.parseBinary(prec+1, noIn)
+		}
+		x = &expr{}
+	}
+}
+
+// parseUnary parses prefix and postfix operators.
+func (p *parser) parseUnary() *expr {
+	switch {
+	case p.tok.Kind == PUNCT && (p.tok.Text == "!" || p.tok.Text == "~" || p.tok.Text == "+" || p.tok.Text == "-"):
+		p.next()
+		p.parseUnary()
+		return &expr{}
+	case p.is("delete") || p.is("void") || p.is("typeof"):
+		p.next()
+		p.parseUnary()
+		return &expr{}
+	case p.is("await") && p.peek().Kind != PUNCT:
+		p.feature(p.tok.Pos, "await expression")
+		p.next()
+		p.parseUnary()
+		return &expr{}
+	case p.tok.Kind == PUNCT && (p.tok.Text == "++" || p.tok.Text == "--"):
+		p.next()
+		if x := p.parseUnary(); x.kind != exprIdent && x.kind != exprMember {
+			p.fail(p.tok.Pos, "invalid increment operand")
+		}
+		return &expr{}
+	}
+
+	x := p.parseLeftHandSide()
+	if (p.is("++") || p.is("--")) && !p.tok.Newline {
+		if x.kind != exprIdent && x.kind != exprMember {
+			p.fail(p.tok.Pos, "invalid increment operand")
+		}
+		p.next()
+		return &expr{}
+	}
+	return x
+}
+
+// parseLeftHandSide parses new, member access and call expressions.
+func (p *parser) parseLeftHandSide() *expr {
+	var x *expr
+	if p.is("new") {
+		start := p.tok
+		p.next()
+		if p.accept(".") {
+			p.feature(start.Pos, "new.target")
+			p.parseIdent()
+			x = &expr{}
+		} else {
+			p.parseNewCallee()
+			if p.is("(") {
+				p.parseArguments(start.Pos, "")
+			}
+			x = &expr{}
+		}
+	} else {
+		x = p.parsePrimary()
+	}
+
+	for {
+		start := p.tok
+		switch {
+		case p.is("."):
+			p.next()
+			if p.tok.Kind != IDENT {
+				p.unexpected()
+			}
+			if x.kind == exprIdent || (x.kind == exprMember && x.name != "") {
+				x = &expr{kind: exprMember, name: x.name + "." + p.tok.Text}
+			} else {
+				x = &expr{kind: exprMember}
+			}
+			p.next()
+		case p.is("?."):
+			p.feature(start.Pos, "optional chaining")
+			p.next()
+			switch {
+			case p.is("("):
+				p.parseArguments(start.Pos, "")
+			case p.accept("["):
+				p.parseExpression(false)
+				p.expect("]")
+			default:
+				if p.tok.Kind != IDENT {
+					p.unexpected()
+				}
+				p.next()
+			}
+			x = &expr{kind: exprMember}
+		case p.is("["):
+			p.next()
+			p.parseExpression(false)
+			p.expect("]")
+			x = &expr{kind: exprMember}
+		case p.is("("):
+			callee := ""
+			if x.kind == exprIdent || x.kind == exprMember {
+				callee = x.name
+			}
+			p.parseArguments(start.Pos, callee)
+			x = &expr{}
+		case p.tok.Kind == TEMPLATE:
+			p.feature(start.Pos, "tagged template")
+			p.next()
+			x = &expr{}
+		default:
+			return x
+		}
+	}
+}
+
+// parseNewCallee parses the member expression of a new expression without call arguments.
+func (p *parser) parseNewCallee() {
+	if p.is("new") {
+		start := p.tok
+		p.next()
+		p.parseNewCallee()
+		if p.is("(") {
+			p.parseArguments(start.Pos, "")
+		}
+		return
+	}
+	p.parsePrimary()
+	for {
+		switch {
+		case p.accept("."):
+			if p.tok.Kind != IDENT {
+				p.unexpected()
+			}
+			p.next()
+		case p.accept("["):
+			p.parseExpression(false)
+			p.expect("]")
+		default:
+			return
+		}
+	}
+}
+
+// parseArguments parses call arguments and records the call.
+func (p *parser) parseArguments(pos script.Pos, callee string) {
+	call := &Call{Callee: callee, Pos: pos, Args: []script.Pos{}, Literals: []*StringLiteral{}}
+	p.expect("(")
+	for !p.accept(")") {
+		argPos := p.tok.Pos
+		if p.is("...") {
+			p.feature(p.tok.Pos, "spread syntax")
+			p.next()
+		}
+		x := p.parseAssign(false)
+		call.Args = append(call.Args, argPos)
+		call.Literals = append(call.Literals, x.str)
+		if !p.is(")") {
+			p.expect(",")
+			if p.is(")") {
+				p.feature(p.tok.Pos, "trailing comma in arguments")
+			}
+		}
+	}
+	p.prog.Calls = append(p.prog.Calls, call)
+}
+
+// parsePrimary parses literals, identifiers, function expressions and parenthesized expressions.
+func (p *parser) parsePrimary() *expr {
+	tok := p.tok
+	switch tok.Kind {
+	case STRING:
+		p.next()
+		lit := &StringLiteral{Raw: tok.Text, Value: tok.Value, Pos: tok.Pos, End: tok.End}
+		p.prog.Strings = append(p.prog.Strings, lit)
+		return &expr{kind: exprString, str: lit}
+	case NUMBER:
+		if len(tok.Text) > 1 && strings.ContainsAny(tok.Text[1:2], "bBoO") {
+			p.feature(tok.Pos, "binary or octal literal")
+		}
+		p.next()
+		return &expr{}
+	case TEMPLATE:
+		p.feature(tok.Pos, "template literal")
+		p.next()
+		return &expr{}
+	case IDENT:
+		switch tok.Text {
+		case "function":
+			p.parseFunction(false)
+			return &expr{}
+		case "class":
+			p.parseClass(false)
+			return &expr{}
+		case "this", "null", "true", "false":
+			p.next()
+			return &expr{}
+		case "super":
+			p.feature(tok.Pos, "super")
+			p.next()
+			return &expr{}
+		}
+		return &expr{kind: exprIdent, name: p.parseIdent()}
+	}
+
+	switch tok.Text {
+	case "/", "/=":
+		p.tok = p.lex.scanRegex(tok)
+		if p.tok.Kind == ILLEGAL {
+			p.fail(p.tok.Pos, p.tok.Value)
+		}
+		flags := p.tok.Text[strings.LastIndex(p.tok.Text, "/")+1:]
+		if strings.ContainsAny(flags, "uysd") {
+			p.feature(tok.Pos, "regular expression flag")
+		}
+		p.next()
+		return &expr{}
+	case "[":
+		p.parseArray()
+		return &expr{kind: exprPattern}
+	case "{":
+		p.parseObject()
+		return &expr{kind: exprPattern}
+	case "(":
+		return p.parseParen()
+	}
+	p.unexpected()
+	return nil
+}
+
+// parseParen parses a parenthesized expression or the parameter list of an arrow function.
+func (p *parser) parseParen() *expr {
+	p.expect("(")
+	if p.accept(")") {
+		if !p.is("=>") {
+			p.fail(p.tok.Pos, "missing => after ()")
+		}
+		return &expr{kind: exprParen}
+	}
+
+	var x *expr
+	count := 0
+	for {
+		if p.is("...") {
+			// rest parameter of an arrow function
+			p.next()
+			p.parseBinding()
+			p.expect(")")
+			if !p.is("=>") {
+				p.fail(p.tok.Pos, "missing => after rest parameter")
+			}
+			return &expr{kind: exprParen}
+		}
+		x = p.parseAssign(false)
+		count++
+		if !p.accept(",") {
+			break
+		}
+		if p.is(")") {
+			p.feature(p.tok.Pos, "trailing comma in parameters")
+			break
+		}
+	}
+	p.expect(")")
+
+	result := &expr{kind: exprParen}
+	if count == 1 && x.kind == exprString {
+		result.str = x.str
+	}
+	if count == 1 && (x.kind == exprIdent || x.kind == exprMember) && !p.is("=>") {
+		// (a.b) = 1 is a valid assignment
+		result.kind = exprMember
+	}
+	return result
+}
+
+// parseArray parses an array literal with optional elisions.
+func (p *parser) parseArray() {
+	p.expect("[")
+	for !p.accept("]") {
+		if p.accept(",") {
+			continue
+		}
+		if p.is("...") {
+			p.feature(p.tok.Pos, "spread syntax")
+			p.next()
+		}
+		p.parseAssign(false)
+		if p.tok.Kind == EOF {
+			p.fail(p.tok.Pos, "missing ] before end of script")
+		}
+		if !p.is("]") {
+			p.expect(",")
+		}
+	}
+}
+
+// parseObject parses an object literal including ES5 accessors and ES6 shorthand forms.
+func (p *parser) parseObject() {
+	p.expect("{")
+	for !p.accept("}") {
+		start := p.tok
+		switch {
+		case p.is("..."):
+			p.feature(start.Pos, "object spread")
+			p.next()
+			p.parseAssign(false)
+		case (p.is("get") || p.is("set")) && !isPropertyEnd(p.peek()):
+			p.next()
+			p.parsePropertyName()
+			p.parseParams()
+			p.parseBlock()
+		case p.is("async") && !isPropertyEnd(p.peek()), p.is("*"):
+			p.feature(start.Pos, "method definition")
+			p.parseMethod()
+		default:
+			p.parsePropertyName()
+			switch {
+			case p.accept(":"):
+				p.parseAssign(false)
+			case p.is("("):
+				p.feature(start.Pos, "method definition")
+				p.parseParams()
+				p.parseBlock()
+			case start.Kind == IDENT && (p.is(",") || p.is("}")):
+				p.feature(start.Pos, "shorthand property")
+			case start.Kind == IDENT && p.is("="):
+				// default value of a destructuring pattern
+				p.feature(start.Pos, "shorthand property")
+				p.next()
+				p.parseAssign(false)
+			default:
+				p.expect(":")
+			}
+		}
+		if p.tok.Kind == EOF {
+			p.fail(p.tok.Pos, "missing } before end of script")
+		}
+		if !p.is("}") {
+			p.expect(",")
+		}
+	}
+}
+
+// isPropertyEnd reports whether the token ends a property name, e.g. in {get: 1}.
+func isPropertyEnd(tok Token) bool {
+	return tok.Kind == PUNCT && (tok.Text == ":" || tok.Text == "(" || tok.Text == "," || tok.Text == "}" || tok.Text == "=")
+}
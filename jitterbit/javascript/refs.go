@@ -0,0 +1,90 @@
+package javascript
+
+import (
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/script"
+)
+
+// Entity reference passed to a Jitterbit function, e.g. RunScript("sc.<id>").
+type Reference struct {
+	Call *Call
+	// Jitterbit Script name of the called function, e.g. DBLookup for Jitterbit.DbLookup.
+	Function string
+	// Index of the referencing argument.
+	Arg int
+	// Accepted entity types.
+	Types []string
+	// String literal argument, nil for dynamic references.
+	Literal *StringLiteral
+	// Entity kind prefix, e.g. sc or op.
+	Prefix string
+	// Entity ID, empty for <TAG> references.
+	Id string
+	// Path of an already resolved reference, e.g. Scripts/Folder/Name.
+	Tag string
+	// Whether the argument is not a string literal and cannot be resolved statically.
+	Dynamic bool
+}
+
+// Pos returns the position of the referencing argument.
+func (ref *Reference) Pos() script.Pos {
+	return ref.Call.Args[ref.Arg]
+}
+
+// refFunction returns the Jitterbit Script function taking entity references called by a plain
+// or Jitterbit. prefixed callee. JavaScript names differ in case, e.g. Jitterbit.DbLookup.
+func refFunction(callee string) (string, bool) {
+	name := strings.TrimPrefix(callee, "Jitterbit.")
+	if strings.Contains(name, ".") {
+		return "", false
+	}
+	for function := range script.RefFunctions {
+		if strings.EqualFold(function, name) {
+			return function, true
+		}
+	}
+	return "", false
+}
+
+// FindReferences returns entity references of the program in source order.
+// Calls with missing arguments are skipped, literals that are neither IDs nor <TAG> paths are reported as dynamic.
+func FindReferences(prog *Program) []*Reference {
+	refs := []*Reference{}
+	for _, call := range prog.Calls {
+		function, ok := refFunction(call.Callee)
+		if !ok {
+			continue
+		}
+
+		for _, param := range script.RefFunctions[function] {
+			if param.Index >= len(call.Args) {
+				continue
+			}
+			ref := &Reference{Call: call, Function: function, Arg: param.Index, Types: param.Types}
+			lit := call.Literals[param.Index]
+			if lit == nil {
+				ref.Dynamic = true
+			} else {
+				ref.Literal = lit
+				ref.Prefix, ref.Id, ref.Tag, ref.Dynamic = script.ParseRefValue(lit.Value)
+			}
+			refs = append(refs, ref)
+		}
+	}
+
+	// calls are recorded when their arguments are complete, nested calls come first
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].Pos().Offset < refs[j].Pos().Offset })
+	return refs
+}
+
+// Quote returns a JavaScript string literal with the value using the quote character of the original literal.
+func Quote(value string, original *StringLiteral) string {
+	quote := "\""
+	if original != nil && strings.HasPrefix(original.Raw, "'") {
+		quote = "'"
+	}
+	escaped := strings.NewReplacer("\\", "\\\\", quote, "\\"+quote, "\n", "\\n", "\r", "\\r").Replace(value)
+	return quote + escaped + quote
+}
@@ -0,0 +1,322 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/catalog"
+	"jbextractor/jitterbit/javascript"
+	jbproj "jbextractor/jitterbit/project"
+	"jbextractor/jitterbit/script"
+
+	"golang.org/x/exp/slices"
+)
+
+// A rule violation found in a script.
+type Diagnostic struct {
+	Rule     string
+	Severity Severity
+	File     string
+	Pos      script.Pos
+	Msg      string
+}
+
+// String returns the diagnostic in file:line:col: severity: message [rule] format.
+func (diag *Diagnostic) String() string {
+	return fmt.Sprintf("%s:%s: %s: %s [%s]", diag.File, diag.Pos, diag.Severity, diag.Msg, diag.Rule)
+}
+
+// Checks whether entity references point to existing entities.
+type Resolver interface {
+	// ResolveTag reports whether a <TAG> path, e.g. Scripts/Folder/Name, exists.
+	ResolveTag(tag string) bool
+	// ResolveId returns the entity type of an entity ID, false if it does not exist.
+	ResolveId(id string) (string, bool)
+}
+
+// A global variable assignment location.
+type location struct {
+	file string
+	pos  script.Pos
+}
+
+// Static analyzer of Jitterbit Script files.
+type Linter struct {
+	config   *Config
+	resolver Resolver
+	diags    []*Diagnostic
+	// Global variable assignments across all linted files.
+	globalWrites map[string][]location
+	// Global variables read by any of the linted files.
+	globalReads map[string]bool
+}
+
+// NewLinter creates a linter with the rule configuration and reference resolver.
+func NewLinter(config *Config, resolver Resolver) *Linter {
+	return &Linter{
+		config:       config,
+		resolver:     resolver,
+		globalWrites: make(map[string][]location),
+		globalReads:  make(map[string]bool),
+	}
+}
+
+// diagnostic creates a diagnostic with the configured severity, nil if the rule is disabled.
+func (linter *Linter) diagnostic(rule string, file string, pos script.Pos, msg string) *Diagnostic {
+	sev := linter.config.Severities[rule]
+	if sev == OFF {
+		return nil
+	}
+	return &Diagnostic{Rule: rule, Severity: sev, File: file, Pos: pos, Msg: msg}
+}
+
+// report records a diagnostic unless the rule is disabled.
+func (linter *Linter) report(rule string, file string, pos script.Pos, msg string) {
+	if diag := linter.diagnostic(rule, file, pos, msg); diag != nil {
+		linter.diags = append(linter.diags, diag)
+	}
+}
+
+// Lint checks a single script, file is used for reporting only.
+func (linter *Linter) Lint(file string, src string) {
+	parsed, errs := script.Parse(src)
+
+	unterminated := make(map[int]bool)
+	for _, block := range parsed.Blocks {
+		if !block.Closed {
+			unterminated[block.Open.Offset] = true
+			linter.report(UNTERMINATED_TRANS, file, block.Open, "<trans> block is not terminated with </trans>")
+		}
+	}
+	for _, err := range errs {
+		if !unterminated[err.Pos.Offset] {
+			linter.report(SYNTAX_ERROR, file, err.Pos, err.Msg)
+		}
+	}
+
+	linter.checkReferences(file, parsed)
+	linter.checkCalls(file, parsed)
+	linter.checkVariables(file, parsed)
+	linter.checkUnreachable(file, parsed)
+}
+
+// LintJavaScript checks a single JavaScript file for ES5 syntax errors, unsupported features and missing references.
+func (linter *Linter) LintJavaScript(file string, src string) {
+	prog, errs := javascript.Parse(src)
+	for _, err := range errs {
+		linter.report(SYNTAX_ERROR, file, err.Pos, err.Msg)
+	}
+	for _, feature := range prog.Features {
+		linter.report(UNSUPPORTED_FEATURE, file, feature.Pos, fmt.Sprintf("%s is not supported by the ES5 engine", feature.Name))
+	}
+
+	if linter.resolver == nil {
+		return
+	}
+	for _, ref := range javascript.FindReferences(prog) {
+		if !ref.Dynamic {
+			linter.checkReference(file, ref.Pos(), ref.Call.Callee, ref.Types, ref.Tag, ref.Id, ref.Literal.Value)
+		}
+	}
+}
+
+// checkReferences reports entity IDs and <TAG> paths which do not exist or have a wrong type.
+func (linter *Linter) checkReferences(file string, parsed *script.Script) {
+	if linter.resolver == nil {
+		return
+	}
+
+	for _, ref := range script.FindReferences(parsed) {
+		if !ref.Dynamic {
+			linter.checkReference(file, ref.Pos(), ref.Call.Name.Name, ref.Types, ref.Tag, ref.Id, ref.Literal.Value)
+		}
+	}
+}
+
+// checkReference reports a <TAG> path or entity ID which does not exist or whose type is not accepted by the function.
+func (linter *Linter) checkReference(file string, pos script.Pos, function string, types []string, tag string, id string, value string) {
+	var typeName string
+	var ok bool
+	switch {
+	case tag != "":
+		typeName, ok = script.TagType(tag), linter.resolver.ResolveTag(tag)
+		value = tag
+	case id != "":
+		typeName, ok = linter.resolver.ResolveId(id)
+	default:
+		return
+	}
+
+	if !ok {
+		linter.report(UNRESOLVED_REFERENCE, file, pos, fmt.Sprintf("%s references a missing entity %s", function, value))
+	} else if !slices.Contains(types, typeName) {
+		linter.report(UNRESOLVED_REFERENCE, file, pos, fmt.Sprintf("%s references the %s %s, expected a %s", function, typeName, value, strings.Join(types, " or ")))
+	}
+}
+
+// checkCalls reports unknown and deprecated functions and wrong argument counts.
+func (linter *Linter) checkCalls(file string, parsed *script.Script) {
+	script.Inspect(parsed, func(node script.Node) bool {
+		call, ok := node.(*script.Call)
+		if !ok {
+			return true
+		}
+
+		name := call.Name.Name
+		fn, ok := catalog.Default().Lookup(name)
+		if !ok {
+			linter.report(UNKNOWN_FUNCTION, file, call.Pos(), fmt.Sprintf("unknown function %s", name))
+			return true
+		}
+
+		if fn.Deprecated {
+			msg := fmt.Sprintf("%s is deprecated", name)
+			if fn.Replacement != "" {
+				msg = fmt.Sprintf("%s is deprecated, use %s", name, fn.Replacement)
+			}
+			linter.report(DEPRECATED_FUNCTION, file, call.Pos(), msg)
+		}
+
+		count, min, max := len(call.Args), fn.MinArgs(), fn.MaxArgs()
+		switch {
+		case min == max && count != min:
+			linter.report(ARGUMENT_COUNT, file, call.Pos(), fmt.Sprintf("%s takes %d argument(s), got %d: %s", name, min, count, fn.Signature()))
+		case count < min:
+			linter.report(ARGUMENT_COUNT, file, call.Pos(), fmt.Sprintf("%s takes at least %d argument(s), got %d: %s", name, min, count, fn.Signature()))
+		case max >= 0 && count > max:
+			linter.report(ARGUMENT_COUNT, file, call.Pos(), fmt.Sprintf("%s takes at most %d argument(s), got %d: %s", name, max, count, fn.Signature()))
+		}
+		return true
+	})
+}
+
+// checkVariables reports unused local variables and records global variable usage.
+func (linter *Linter) checkVariables(file string, parsed *script.Script) {
+	// nodes which are only written to
+	writes := make(map[script.Node]bool)
+	// function names
+	callees := make(map[script.Node]bool)
+	script.Inspect(parsed, func(node script.Node) bool {
+		switch n := node.(type) {
+		case *script.Assign:
+			if n.Op == script.ASSIGN {
+				writes[n.Target] = true
+			}
+		case *script.Call:
+			callees[n.Name] = true
+		}
+		return true
+	})
+
+	localWrites := make(map[string]script.Pos)
+	localReads := make(map[string]bool)
+	script.Inspect(parsed, func(node script.Node) bool {
+		switch n := node.(type) {
+		case *script.Ident:
+			if callees[n] {
+				return true
+			}
+			if !writes[n] {
+				localReads[n.Name] = true
+			} else if _, ok := localWrites[n.Name]; !ok {
+				localWrites[n.Name] = n.Pos()
+			}
+		}
+		return true
+	})
+
+	for _, access := range script.GlobalAccesses(parsed) {
+		if access.Write {
+			linter.globalWrites[access.Name] = append(linter.globalWrites[access.Name], location{file, access.Pos})
+		}
+		if access.Read {
+			linter.globalReads[access.Name] = true
+		}
+	}
+
+	for name, pos := range localWrites {
+		if !localReads[name] {
+			linter.report(UNUSED_VARIABLE, file, pos, fmt.Sprintf("local variable %s is assigned but never used", name))
+		}
+	}
+}
+
+// checkUnreachable reports statements following a RaiseError call.
+func (linter *Linter) checkUnreachable(file string, parsed *script.Script) {
+	check := func(stmts []script.Expr) {
+		for idx, stmt := range stmts {
+			call, ok := stmt.(*script.Call)
+			if ok && call.Name.Name == "RaiseError" && idx+1 < len(stmts) {
+				linter.report(UNREACHABLE_CODE, file, stmts[idx+1].Pos(), "unreachable code after RaiseError")
+				return
+			}
+		}
+	}
+
+	script.Inspect(parsed, func(node script.Node) bool {
+		switch n := node.(type) {
+		case *script.Block:
+			check(n.Body)
+		case *script.Sequence:
+			check(n.Exprs)
+		}
+		return true
+	})
+}
+
+// Diagnostics returns all findings ordered by file and position, including the cross-file global variable checks.
+func (linter *Linter) Diagnostics() []*Diagnostic {
+	diags := append([]*Diagnostic{}, linter.diags...)
+	for name, locs := range linter.globalWrites {
+		// system variables are read by the Jitterbit engine
+		if linter.globalReads[name] || strings.HasPrefix(strings.ToLower(name), "jitterbit.") {
+			continue
+		}
+		for _, loc := range locs {
+			diag := linter.diagnostic(UNREAD_GLOBAL, loc.file, loc.pos, fmt.Sprintf("global variable $%s is assigned but never read", name))
+			if diag != nil {
+				diags = append(diags, diag)
+			}
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].File != diags[j].File {
+			return diags[i].File < diags[j].File
+		}
+		return diags[i].Pos.Offset < diags[j].Pos.Offset
+	})
+	return diags
+}
+
+// LintDir checks all Jitterbit Script and JavaScript files of an extracted project directory.
+func LintDir(root string, sep string, config *Config) ([]*Diagnostic, error) {
+	linter := NewLinter(config, &jbproj.DirResolver{Root: root, Sep: sep})
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (!strings.HasSuffix(path, ".jb") && !strings.HasSuffix(path, ".js")) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, ".js") {
+			linter.LintJavaScript(path, string(data))
+		} else {
+			linter.Lint(path, string(data))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return linter.Diagnostics(), nil
+}
@@ -0,0 +1,44 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	jbproj "jbextractor/jitterbit/project"
+)
+
+func TestLintDirWithoutRawXml(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"Script/Utils/helper.jb":            `<trans>RunOperation("<TAG>Operations/Orders/Sync Orders</TAG>")</trans>`,
+		"Script/jsscript.js":                `RunOperation("<TAG>Operations/Orders/Sync Orders</TAG>");`,
+		"Operation/Orders/Sync Orders.md":   "# Sync Orders\n",
+		"Operation/Orders/Sync Orders.yaml": "name: Sync Orders\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diags, err := LintDir(root, string(filepath.Separator), DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, diag := range diags {
+		if diag.Rule == UNRESOLVED_REFERENCE {
+			t.Errorf("unexpected diagnostic %s", diag)
+		}
+	}
+
+	resolver := &jbproj.DirResolver{Root: root, Sep: string(filepath.Separator)}
+	path, ok := resolver.TagPath("Operations/Orders/Sync Orders")
+	if want := filepath.Join(root, "Operation", "Orders", "Sync Orders.yaml"); !ok || path != want {
+		t.Errorf("TagPath = %s, %v, want %s", path, ok, want)
+	}
+}
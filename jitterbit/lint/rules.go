@@ -0,0 +1,121 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Diagnostic importance level.
+type Severity int
+
+// Severity level, Off disables a rule.
+const (
+	OFF Severity = iota
+	INFO
+	WARNING
+	ERROR
+)
+
+var severityNames = []string{"off", "info", "warning", "error"}
+
+// String returns the lowercase severity name.
+func (sev Severity) String() string {
+	if int(sev) < len(severityNames) {
+		return severityNames[sev]
+	}
+	return fmt.Sprintf("Severity(%d)", int(sev))
+}
+
+// ParseSeverity converts a severity name to its level.
+func ParseSeverity(name string) (Severity, error) {
+	for idx, sevName := range severityNames {
+		if strings.EqualFold(name, sevName) {
+			return Severity(idx), nil
+		}
+	}
+	return OFF, fmt.Errorf("unknown severity %q", name)
+}
+
+// Rule identifier.
+const (
+	SYNTAX_ERROR         string = "syntax-error"
+	UNTERMINATED_TRANS   string = "unterminated-trans"
+	UNRESOLVED_REFERENCE string = "unresolved-reference"
+	UNKNOWN_FUNCTION     string = "unknown-function"
+	ARGUMENT_COUNT       string = "argument-count"
+	UNUSED_VARIABLE      string = "unused-variable"
+	UNREAD_GLOBAL        string = "unread-global"
+	UNREACHABLE_CODE     string = "unreachable-code"
+	DEPRECATED_FUNCTION  string = "deprecated-function"
+	UNSUPPORTED_FEATURE  string = "unsupported-feature"
+)
+
+// A static check with its default severity.
+type Rule struct {
+	Id          string
+	Description string
+	Severity    Severity
+}
+
+// All available rules.
+var Rules = []Rule{
+	{SYNTAX_ERROR, "Script cannot be parsed", ERROR},
+	{UNTERMINATED_TRANS, "<trans> block is missing its </trans> tag", ERROR},
+	{UNRESOLVED_REFERENCE, "Referenced entity does not exist or has a wrong type", ERROR},
+	{UNKNOWN_FUNCTION, "Called function is not a Jitterbit built-in", WARNING},
+	{ARGUMENT_COUNT, "Built-in function is called with a wrong number of arguments", ERROR},
+	{UNUSED_VARIABLE, "Local variable is assigned but never read", WARNING},
+	{UNREAD_GLOBAL, "Global variable is assigned but never read by any script", INFO},
+	{UNREACHABLE_CODE, "Code after RaiseError is never executed", WARNING},
+	{DEPRECATED_FUNCTION, "Called built-in function is deprecated", INFO},
+	{UNSUPPORTED_FEATURE, "JavaScript uses ES6+ syntax not supported by the ES5 engine", ERROR},
+}
+
+// Rule severity overrides.
+type Config struct {
+	Severities map[string]Severity
+}
+
+// Config file format, e.g. {"rules": {"unused-variable": "off"}}.
+type configFile struct {
+	Rules map[string]string `json:"rules"`
+}
+
+// DefaultConfig returns the default rule severities.
+func DefaultConfig() *Config {
+	config := Config{Severities: make(map[string]Severity)}
+	for _, rule := range Rules {
+		config.Severities[rule.Id] = rule.Severity
+	}
+	return &config
+}
+
+// LoadConfig reads severity overrides from a JSON file on top of the defaults.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file configFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+
+	config := DefaultConfig()
+	for id, name := range file.Rules {
+		if _, ok := config.Severities[id]; !ok {
+			return nil, fmt.Errorf("[LoadConfig] Unknown rule %s", id)
+		}
+		sev, err := ParseSeverity(name)
+		if err != nil {
+			return nil, fmt.Errorf("[LoadConfig] Rule %s: %s", id, err.Error())
+		}
+		config.Severities[id] = sev
+	}
+
+	return config, nil
+}
@@ -0,0 +1,68 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Base protocol transport with Content-Length framed JSON-RPC messages.
+type conn struct {
+	reader *bufio.Reader
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+// newConn creates a connection over the input and output streams.
+func newConn(in io.Reader, out io.Writer) *conn {
+	return &conn{reader: bufio.NewReader(in), writer: out}
+}
+
+// read returns the next message content.
+func (c *conn) read() ([]byte, error) {
+	length := -1
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		// end of headers
+		if line == "" {
+			break
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if found && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("[ReadMessage] Invalid Content-Length: %s", value)
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("[ReadMessage] Missing Content-Length header")
+	}
+
+	content := make([]byte, length)
+	_, err := io.ReadFull(c.reader, content)
+	return content, err
+}
+
+// write sends a message.
+func (c *conn) write(msg interface{}) error {
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, err = fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	return err
+}
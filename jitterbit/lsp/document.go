@@ -0,0 +1,99 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"jbextractor/jitterbit/script"
+)
+
+// A parsed script file.
+type document struct {
+	path   string
+	text   string
+	script *script.Script
+	errs   []*script.Error
+	// Byte offsets of line starts.
+	lines []int
+}
+
+// newDocument parses the script text.
+func newDocument(path string, text string) *document {
+	parsed, errs := script.Parse(text)
+	doc := &document{path: path, text: text, script: parsed, errs: errs, lines: []int{0}}
+	for idx := 0; idx < len(text); idx++ {
+		if text[idx] == '\n' {
+			doc.lines = append(doc.lines, idx+1)
+		}
+	}
+	return doc
+}
+
+// position converts a script position to a zero-based line and UTF-16 character offset.
+func (doc *document) position(pos script.Pos) Position {
+	if pos.Line < 1 || pos.Line > len(doc.lines) {
+		return Position{}
+	}
+	start := doc.lines[pos.Line-1]
+	end := pos.Offset
+	if end > len(doc.text) {
+		end = len(doc.text)
+	}
+	return Position{Line: pos.Line - 1, Character: utf16Len(doc.text[start:end])}
+}
+
+// rangeOf returns the range of a syntax tree node.
+func (doc *document) rangeOf(node script.Node) Range {
+	return Range{Start: doc.position(node.Pos()), End: doc.position(node.End())}
+}
+
+// offset converts a zero-based line and UTF-16 character offset to a byte offset.
+func (doc *document) offset(pos Position) int {
+	if pos.Line < 0 || pos.Line >= len(doc.lines) {
+		return len(doc.text)
+	}
+	offset := doc.lines[pos.Line]
+	units := 0
+	for offset < len(doc.text) && units < pos.Character && doc.text[offset] != '\n' {
+		r, size := utf8.DecodeRuneInString(doc.text[offset:])
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+// utf16Len returns the number of UTF-16 code units of the text.
+func utf16Len(text string) int {
+	units := 0
+	for _, r := range text {
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return units
+}
+
+// uriToPath converts a file URI to a local path.
+func uriToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+	path := parsed.Path
+	// file:///C:/dir on Windows
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
+
+// pathToURI converts a local path to a file URI.
+func pathToURI(path string) string {
+	slashed := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String()
+}
@@ -0,0 +1,442 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/catalog"
+	jbproj "jbextractor/jitterbit/project"
+	"jbextractor/jitterbit/script"
+)
+
+// Language server for extracted Jitterbit Script files.
+type Server struct {
+	conn *conn
+	// Extraction directory.
+	root string
+	sep  string
+	// Open documents by path, these take precedence over the files on disk.
+	open map[string]*document
+	// Whether the shutdown request was received.
+	shutdown bool
+	logger   io.Writer
+}
+
+// Serve runs the language server until the exit notification or the end of input.
+// If root is empty, the extraction directory is determined from the workspace root sent by the client.
+func Serve(in io.Reader, out io.Writer, root string, sep string) error {
+	// document URIs are built from absolute paths
+	if root != "" {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+		root = abs
+	}
+	server := &Server{
+		conn:   newConn(in, out),
+		root:   root,
+		sep:    sep,
+		open:   make(map[string]*document),
+		logger: os.Stderr,
+	}
+
+	for {
+		content, err := server.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			server.conn.write(response{JSONRPC: "2.0", Error: &responseError{PARSE_ERROR, err.Error()}})
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, rpcErr := server.handle(&req)
+		// notifications have no response
+		if req.Id == nil {
+			if rpcErr != nil {
+				fmt.Fprintf(server.logger, "[LSP] %s: %s\n", req.Method, rpcErr.Message)
+			}
+			continue
+		}
+		if err := server.conn.write(response{JSONRPC: "2.0", Id: req.Id, Result: result, Error: rpcErr}); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a request or notification.
+func (server *Server) handle(req *request) (interface{}, *responseError) {
+	switch req.Method {
+	case "initialize":
+		var params InitializeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{INVALID_PARAMS, err.Error()}
+		}
+		return server.initialize(&params), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		server.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{INVALID_PARAMS, err.Error()}
+		}
+		server.update(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{INVALID_PARAMS, err.Error()}
+		}
+		if len(params.ContentChanges) > 0 {
+			server.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{INVALID_PARAMS, err.Error()}
+		}
+		delete(server.open, uriToPath(params.TextDocument.URI))
+		server.publish(params.TextDocument.URI, nil)
+		return nil, nil
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{INVALID_PARAMS, err.Error()}
+		}
+		return server.definition(&params), nil
+	case "textDocument/references":
+		var params ReferenceParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{INVALID_PARAMS, err.Error()}
+		}
+		return server.references(&params.TextDocumentPositionParams), nil
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{INVALID_PARAMS, err.Error()}
+		}
+		return server.hover(&params), nil
+	}
+
+	// optional notifications, e.g. $/cancelRequest, are ignored
+	if req.Id == nil {
+		return nil, nil
+	}
+	return nil, &responseError{METHOD_NOT_FOUND, fmt.Sprintf("method %s is not supported", req.Method)}
+}
+
+// initialize determines the extraction directory and returns the server capabilities.
+func (server *Server) initialize(params *InitializeParams) *InitializeResult {
+	if server.root == "" {
+		if params.RootURI != "" {
+			server.root = uriToPath(params.RootURI)
+		} else {
+			server.root = params.RootPath
+		}
+	}
+	server.root = findExtractionRoot(server.root)
+	fmt.Fprintf(server.logger, "[LSP] Extraction directory: %s\n", server.root)
+
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:   TEXT_SYNC_FULL,
+			DefinitionProvider: true,
+			ReferencesProvider: true,
+			HoverProvider:      true,
+		},
+		ServerInfo: ServerInfo{Name: "jbextractor"},
+	}
+}
+
+// findExtractionRoot returns the closest directory with the copied project manifest (project.properties) as an absolute path.
+func findExtractionRoot(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	dir := path
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "project.properties")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path
+		}
+		dir = parent
+	}
+}
+
+// update stores the open document content and publishes its syntax errors.
+func (server *Server) update(uri string, text string) {
+	path := uriToPath(uri)
+	if !strings.HasSuffix(path, ".jb") {
+		return
+	}
+	doc := newDocument(path, text)
+	server.open[path] = doc
+	server.publish(uri, doc)
+}
+
+// publish sends the parser diagnostics of a document, nil clears them.
+func (server *Server) publish(uri string, doc *document) {
+	diags := []Diagnostic{}
+	if doc != nil {
+		for _, err := range doc.errs {
+			pos := doc.position(err.Pos)
+			diags = append(diags, Diagnostic{
+				Range:    Range{Start: pos, End: Position{Line: pos.Line, Character: pos.Character + 1}},
+				Severity: SEVERITY_ERROR,
+				Source:   "jitterbit",
+				Message:  err.Msg,
+			})
+		}
+	}
+	server.conn.write(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  PublishDiagnosticsParams{URI: uri, Diagnostics: diags},
+	})
+}
+
+// document returns an open document or parses the file from disk.
+func (server *Server) document(path string) *document {
+	if doc, ok := server.open[path]; ok {
+		return doc
+	}
+	if !strings.HasSuffix(path, ".jb") {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return newDocument(path, string(data))
+}
+
+// documents returns all Jitterbit Script files of the extraction directory in path order.
+func (server *Server) documents() []*document {
+	paths := map[string]bool{}
+	filepath.WalkDir(server.root, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(path, ".jb") {
+			paths[path] = true
+		}
+		return nil
+	})
+	for path := range server.open {
+		paths[path] = true
+	}
+
+	sorted := []string{}
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	docs := []*document{}
+	for _, path := range sorted {
+		if doc := server.document(path); doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// resolver returns the <TAG> path resolver of the extraction directory.
+func (server *Server) resolver() *jbproj.DirResolver {
+	return &jbproj.DirResolver{Root: server.root, Sep: server.sep}
+}
+
+// referenceAt returns the entity reference whose argument contains the offset.
+func referenceAt(doc *document, offset int) *script.Reference {
+	for _, ref := range script.FindReferences(doc.script) {
+		if ref.Literal != nil && ref.Literal.Pos().Offset <= offset && offset < ref.Literal.End().Offset {
+			return ref
+		}
+	}
+	return nil
+}
+
+// globalAt returns the global variable name at the offset, including names passed to Get and Set.
+func globalAt(doc *document, offset int) (string, bool) {
+	var name string
+	found := false
+	script.Inspect(doc.script, func(node script.Node) bool {
+		if found || node.Pos().Offset > offset || offset >= node.End().Offset {
+			return false
+		}
+		switch n := node.(type) {
+		case *script.GlobalVar:
+			name, found = n.Name, true
+		case *script.Call:
+			if lit := globalNameLiteral(n); lit != nil && lit.Pos().Offset <= offset && offset < lit.End().Offset {
+				name, found = lit.Value, true
+			}
+		}
+		return !found
+	})
+	return name, found
+}
+
+// globalNameLiteral returns the variable name literal of Get and Set calls.
+func globalNameLiteral(call *script.Call) *script.StringLit {
+	if (call.Name.Name != "Get" && call.Name.Name != "Set") || len(call.Args) == 0 {
+		return nil
+	}
+	lit, _ := call.Args[0].(*script.StringLit)
+	return lit
+}
+
+// globalOccurrences returns all reads and writes of a global variable, writesOnly limits them to assignments.
+func (server *Server) globalOccurrences(name string, writesOnly bool) []Location {
+	locs := []Location{}
+	for _, doc := range server.documents() {
+		uri := pathToURI(doc.path)
+		script.Inspect(doc.script, func(node script.Node) bool {
+			switch n := node.(type) {
+			case *script.Assign:
+				if global, ok := n.Target.(*script.GlobalVar); ok && writesOnly && global.Name == name {
+					locs = append(locs, Location{URI: uri, Range: doc.rangeOf(global)})
+				}
+			case *script.GlobalVar:
+				if !writesOnly && n.Name == name {
+					locs = append(locs, Location{URI: uri, Range: doc.rangeOf(n)})
+				}
+			case *script.Call:
+				lit := globalNameLiteral(n)
+				if lit != nil && lit.Value == name && (!writesOnly || n.Name.Name == "Set") {
+					locs = append(locs, Location{URI: uri, Range: doc.rangeOf(lit)})
+				}
+			}
+			return true
+		})
+	}
+	return locs
+}
+
+// definition resolves <TAG> references to the extracted files and global variables to their assignments.
+func (server *Server) definition(params *TextDocumentPositionParams) []Location {
+	doc := server.document(uriToPath(params.TextDocument.URI))
+	if doc == nil {
+		return []Location{}
+	}
+	offset := doc.offset(params.Position)
+
+	if ref := referenceAt(doc, offset); ref != nil && ref.Tag != "" {
+		path, ok := server.resolver().TagPath(ref.Tag)
+		if !ok || path == "" {
+			return []Location{}
+		}
+		return []Location{{URI: pathToURI(path), Range: Range{}}}
+	}
+
+	if name, ok := globalAt(doc, offset); ok {
+		return server.globalOccurrences(name, true)
+	}
+	return []Location{}
+}
+
+// references finds calls of the referenced or current script and occurrences of global variables.
+func (server *Server) references(params *TextDocumentPositionParams) []Location {
+	path := uriToPath(params.TextDocument.URI)
+	doc := server.document(path)
+	if doc == nil {
+		return []Location{}
+	}
+	offset := doc.offset(params.Position)
+
+	if name, ok := globalAt(doc, offset); ok {
+		return server.globalOccurrences(name, false)
+	}
+
+	target := path
+	if ref := referenceAt(doc, offset); ref != nil && ref.Tag != "" {
+		resolved, ok := server.resolver().TagPath(ref.Tag)
+		if !ok || resolved == "" {
+			return []Location{}
+		}
+		target = resolved
+	}
+
+	locs := []Location{}
+	resolver := server.resolver()
+	for _, other := range server.documents() {
+		for _, ref := range script.FindReferences(other.script) {
+			if ref.Tag == "" {
+				continue
+			}
+			if resolved, ok := resolver.TagPath(ref.Tag); ok && samePath(resolved, target) {
+				locs = append(locs, Location{URI: pathToURI(other.path), Range: other.rangeOf(ref.Literal)})
+			}
+		}
+	}
+	return locs
+}
+
+// samePath compares file paths after cleaning.
+func samePath(a string, b string) bool {
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// hover describes built-in functions.
+func (server *Server) hover(params *TextDocumentPositionParams) *Hover {
+	doc := server.document(uriToPath(params.TextDocument.URI))
+	if doc == nil {
+		return nil
+	}
+
+	var call *script.Call
+	offset := doc.offset(params.Position)
+	script.Inspect(doc.script, func(node script.Node) bool {
+		if node.Pos().Offset > offset || offset >= node.End().Offset {
+			return false
+		}
+		if n, ok := node.(*script.Call); ok && offset < n.Name.End().Offset {
+			call = n
+		}
+		return true
+	})
+	if call == nil {
+		return nil
+	}
+
+	fn, ok := catalog.Default().Lookup(call.Name.Name)
+	if !ok {
+		return nil
+	}
+	nameRange := doc.rangeOf(call.Name)
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: describeFunction(fn)},
+		Range:    &nameRange,
+	}
+}
+
+// describeFunction returns the hover text of a built-in function.
+func describeFunction(fn *catalog.Function) string {
+	text := fmt.Sprintf("```\n%s\n```\n%s built-in function\n\n%s", fn.Signature(), fn.Category, fn.Description)
+	if fn.Deprecated {
+		if fn.Replacement != "" {
+			text += fmt.Sprintf("\n\n**Deprecated**, use %s instead.", fn.Replacement)
+		} else {
+			text += "\n\n**Deprecated.**"
+		}
+	}
+	return text
+}
@@ -0,0 +1,85 @@
+package operation
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"jbextractor/jitterbit/entity"
+)
+
+// Prefix of the comments added by Annotate.
+const ANNOTATION_PREFIX = "<!-- jbextractor: "
+
+// Comments added by Annotate, their text contains no "--".
+var annotation = regexp.MustCompile(regexp.QuoteMeta(ANNOTATION_PREFIX) + `.*? -->`)
+
+// Annotate inserts a comment with the resolved entity path and the relative extracted file before each
+// activity of the operation XML, e.g. <!-- jbextractor: Script/Utils/helper (../Script/Utils/helper.jb) -->.
+// StripAnnotations restores the original file.
+func (d *Describer) Annotate(op *Operation, data []byte) ([]byte, error) {
+	refs := make(map[string]*Ref)
+	for _, act := range op.Activities {
+		refs[act.Content.Id] = act.Content
+	}
+
+	var result bytes.Buffer
+	last := 0
+	stack := []string{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("[Annotate] %s", err.Error())
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if strings.Join(stack, "/") == "Entity/Pipeline/Activities" {
+				if id := entity.AttrValue(t, "contentId"); id != "" {
+					ref, ok := refs[id]
+					if !ok {
+						ref = d.Resolve(id)
+					}
+					result.Write(data[last:start])
+					result.WriteString(d.annotation(op, ref))
+					last = start
+				}
+			}
+			stack = append(stack, t.Name.Local)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	result.Write(data[last:])
+	return result.Bytes(), nil
+}
+
+// annotation returns the comment describing an activity content.
+func (d *Describer) annotation(op *Operation, ref *Ref) string {
+	text := "missing entity " + ref.Id
+	if !ref.Missing {
+		text = ref.Path()
+		if target := d.relPath(op, ref, ".xml"); target != "" {
+			text = fmt.Sprintf("%s (%s)", text, target)
+		}
+	}
+	// annotations are single-line comments, which must not contain "--"
+	text = strings.NewReplacer("\r", " ", "\n", " ").Replace(text)
+	for strings.Contains(text, "--") {
+		text = strings.ReplaceAll(text, "--", "- -")
+	}
+	return ANNOTATION_PREFIX + text + " -->"
+}
+
+// StripAnnotations removes the comments added by Annotate.
+func StripAnnotations(data []byte) []byte {
+	return annotation.ReplaceAll(data, nil)
+}
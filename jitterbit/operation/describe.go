@@ -0,0 +1,156 @@
+package operation
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+
+	"jbextractor/jitterbit/entity"
+	jbproj "jbextractor/jitterbit/project"
+	"jbextractor/jitterbit/xref"
+)
+
+// A referenced project entity.
+type Ref struct {
+	Id   string
+	Type string
+	Name string
+	// Parent folder names.
+	Folders []string
+	// Whether the ID was not found in project.xml.
+	Missing bool
+}
+
+// Path returns the slash-separated entity path, e.g. Script/Folder/Name.
+func (ref *Ref) Path() string {
+	if ref.Missing {
+		return ref.Id
+	}
+	return strings.Join(append(append([]string{ref.Type}, ref.Folders...), ref.Name), "/")
+}
+
+// An operation step with its resolved content.
+type Activity struct {
+	Id string
+	// Step kind, e.g. source, target, transformation or script.
+	Role string
+	// Numeric activity type.
+	Type    string
+	Content *Ref
+}
+
+// An operation with its resolved activities, schedule and related operations and scripts.
+type Operation struct {
+	Ref
+	// Pipeline operation type.
+	OpType     string
+	Activities []*Activity
+	Schedules  []*Ref
+	// Operations chained to run after a successful run.
+	OnSuccess []*Ref
+	// Operations chained to run after a failed run.
+	OnFailure []*Ref
+	// Scripts run by the activities, including the scripts they run.
+	Scripts []*Ref
+	// Operation properties in entity file order.
+	Properties []entity.Item
+}
+
+// Resolves operations against the project structure.
+type Describer struct {
+	project *jbproj.Project
+	// Script bodies by ID.
+	scripts map[string]string
+}
+
+// NewDescriber reads the scripts of the project environment to follow their references.
+func NewDescriber(project *jbproj.Project, sep string) (*Describer, error) {
+	d := &Describer{project: project, scripts: make(map[string]string)}
+	scripts, err := project.ParseEntities(jbproj.SCRIPT, sep)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, script := range scripts {
+		d.scripts[script.Header.Id] = script.KongaString
+	}
+	return d, nil
+}
+
+// Resolve returns the type, name and folders of an entity ID.
+func (d *Describer) Resolve(id string) *Ref {
+	et, ent, folders := d.project.FindEntity(id)
+	if ent == nil {
+		return &Ref{Id: id, Missing: true}
+	}
+	return &Ref{Id: id, Type: et.Name, Name: ent.Name, Folders: folders}
+}
+
+// Describe resolves an operation entity. Schedules and chained operations are found among the entity IDs
+// in operation properties and activities, chains are failure chains if the key or role mentions a failure or error.
+func (d *Describer) Describe(op *entity.Entity) *Operation {
+	desc := &Operation{Ref: *d.Resolve(op.Header.Id), OpType: op.Pipeline.OpType, Properties: op.Props.Items}
+	if desc.Missing {
+		desc.Name = op.Header.Name
+		desc.Type = jbproj.OPERATION
+	}
+
+	chain := func(ref *Ref, kind string) {
+		switch {
+		case ref.Missing || ref.Id == desc.Id:
+		case ref.Type == jbproj.SCHEDULE:
+			desc.Schedules = appendRef(desc.Schedules, ref)
+		case ref.Type != jbproj.OPERATION:
+		case isFailure(kind):
+			desc.OnFailure = appendRef(desc.OnFailure, ref)
+		default:
+			desc.OnSuccess = appendRef(desc.OnSuccess, ref)
+		}
+	}
+
+	for _, item := range op.Props.Items {
+		if item.Value != "" && !item.Enc {
+			chain(d.Resolve(item.Value), item.Key)
+		}
+	}
+	for _, act := range op.Pipeline.Activities.Activities {
+		ref := d.Resolve(act.ContentId)
+		desc.Activities = append(desc.Activities, &Activity{Id: act.Id, Role: act.Role, Type: act.Type, Content: ref})
+		chain(ref, act.Role)
+		if ref.Type == jbproj.SCRIPT {
+			desc.Scripts = d.addScripts(desc.Scripts, ref)
+		}
+	}
+	return desc
+}
+
+// addScripts appends a script and the scripts it runs, each once.
+func (d *Describer) addScripts(scripts []*Ref, script *Ref) []*Ref {
+	for _, ref := range scripts {
+		if ref.Id == script.Id {
+			return scripts
+		}
+	}
+	scripts = append(scripts, script)
+	for _, id := range xref.ScriptReferences(d.scripts[script.Id]) {
+		if ref := d.Resolve(id); ref.Type == jbproj.SCRIPT {
+			scripts = d.addScripts(scripts, ref)
+		}
+	}
+	return scripts
+}
+
+// appendRef appends an entity unless it is already listed.
+func appendRef(refs []*Ref, ref *Ref) []*Ref {
+	for _, r := range refs {
+		if r.Id == ref.Id {
+			return refs
+		}
+	}
+	return append(refs, ref)
+}
+
+// isFailure reports whether a property key or activity role denotes a failure chain.
+func isFailure(kind string) bool {
+	kind = strings.ToLower(kind)
+	return strings.Contains(kind, "fail") || strings.Contains(kind, "error")
+}
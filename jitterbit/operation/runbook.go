@@ -0,0 +1,102 @@
+package operation
+
+import (
+	"fmt"
+	"strings"
+
+	jbproj "jbextractor/jitterbit/project"
+	"jbextractor/jitterbit/xref"
+)
+
+// Runbook returns a Markdown runbook of the operation. Links to operations and scripts are relative to
+// the runbook written next to the extracted operation XML.
+func (d *Describer) Runbook(op *Operation) string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "# %s\n\n", jbproj.EscapeMarkdown(op.Name))
+
+	folder := strings.Join(op.Folders, "/")
+	if folder == "" {
+		folder = "/"
+	}
+	opType := op.OpType
+	if opType == "" {
+		opType = "unknown"
+	}
+	md.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&md, "| Folder | %s |\n", jbproj.EscapeMarkdown(folder))
+	fmt.Fprintf(&md, "| Operation type | %s |\n", jbproj.EscapeMarkdown(opType))
+	fmt.Fprintf(&md, "| ID | `%s` |\n", op.Id)
+
+	md.WriteString("\n## Schedule\n\n")
+	d.writeList(&md, op, op.Schedules, "Not scheduled.")
+
+	md.WriteString("\n## Activities\n\n")
+	if len(op.Activities) == 0 {
+		md.WriteString("No activities.\n")
+	} else {
+		md.WriteString("| # | Role | Type | Entity |\n|---|---|---|---|\n")
+		for idx, act := range op.Activities {
+			fmt.Fprintf(&md, "| %d | %s | %s | %s |\n", idx+1, jbproj.EscapeMarkdown(act.Role), act.Content.Type, d.link(op, act.Content))
+		}
+	}
+
+	md.WriteString("\n## On success\n\n")
+	d.writeList(&md, op, op.OnSuccess, "No chained operations.")
+	md.WriteString("\n## On failure\n\n")
+	d.writeList(&md, op, op.OnFailure, "No chained operations.")
+	md.WriteString("\n## Scripts\n\n")
+	d.writeList(&md, op, op.Scripts, "No scripts.")
+	return md.String()
+}
+
+// writeList writes a bullet list of entity links or the text for an empty list.
+func (d *Describer) writeList(md *strings.Builder, op *Operation, refs []*Ref, empty string) {
+	if len(refs) == 0 {
+		md.WriteString(empty + "\n")
+		return
+	}
+	for _, ref := range refs {
+		fmt.Fprintf(md, "- %s\n", d.link(op, ref))
+	}
+}
+
+// link returns a Markdown link to the extracted file of an operation or script, other entities are shown by their path.
+func (d *Describer) link(op *Operation, ref *Ref) string {
+	if ref.Missing {
+		return fmt.Sprintf("`%s` (missing)", ref.Id)
+	}
+	target := d.relPath(op, ref, ".md")
+	if target == "" {
+		return jbproj.EscapeMarkdown(ref.Path())
+	}
+	return fmt.Sprintf("[%s](<%s>)", jbproj.EscapeMarkdown(ref.Path()), target)
+}
+
+// relPath returns the slash-separated path of an extracted operation or script file relative to the folder of
+// the operation, empty for other entities. Operations are linked with the file extension opExt.
+func (d *Describer) relPath(op *Operation, ref *Ref, opExt string) string {
+	ext := ""
+	switch ref.Type {
+	case jbproj.OPERATION:
+		ext = opExt
+	case jbproj.SCRIPT:
+		ext = ".jb"
+		if _, ok := xref.JavaScriptCode(d.scripts[ref.Id]); ok {
+			ext = ".js"
+		}
+	default:
+		return ""
+	}
+
+	// the operation files are in Operation/<folders>
+	target := []string{}
+	for range append([]string{op.Type}, op.Folders...) {
+		target = append(target, "..")
+	}
+	target = append(target, ref.Type)
+	for _, folder := range ref.Folders {
+		target = append(target, jbproj.SanitizeFileName(folder))
+	}
+	target = append(target, jbproj.SanitizeFileName(ref.Name)+ext)
+	return strings.Join(target, "/")
+}
@@ -0,0 +1,94 @@
+package operation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"jbextractor/jitterbit/secrets"
+)
+
+// Scalars which can be written without quotes.
+var plainScalar = regexp.MustCompile(`^[A-Za-z_/][\w ./()-]*$`)
+
+// Plain scalars read as other types than strings.
+var reservedScalar = regexp.MustCompile(`^(?i:true|false|yes|no|on|off|y|n|null|~)$`)
+
+// YAML returns a readable YAML definition of the operation with resolved activity contents, schedules,
+// chained operations and properties. Entity IDs in property values are annotated with the entity path.
+func (d *Describer) YAML(op *Operation) string {
+	var y strings.Builder
+	fmt.Fprintf(&y, "name: %s\n", yamlScalar(op.Name))
+	fmt.Fprintf(&y, "id: %s\n", yamlScalar(op.Id))
+	fmt.Fprintf(&y, "folder: %s\n", yamlScalar(strings.Join(op.Folders, "/")))
+	fmt.Fprintf(&y, "opType: %s\n", yamlScalar(op.OpType))
+
+	writeRefs(&y, "schedules", op.Schedules)
+	writeRefs(&y, "onSuccess", op.OnSuccess)
+	writeRefs(&y, "onFailure", op.OnFailure)
+
+	if len(op.Activities) == 0 {
+		y.WriteString("activities: []\n")
+	} else {
+		y.WriteString("activities:\n")
+		for _, act := range op.Activities {
+			fmt.Fprintf(&y, "  - role: %s\n", yamlScalar(act.Role))
+			fmt.Fprintf(&y, "    activityType: %s\n", yamlScalar(act.Type))
+			if act.Content.Missing {
+				fmt.Fprintf(&y, "    missing: %s\n", yamlScalar(act.Content.Id))
+				continue
+			}
+			fmt.Fprintf(&y, "    type: %s\n", yamlScalar(act.Content.Type))
+			fmt.Fprintf(&y, "    path: %s\n", yamlScalar(strings.Join(append(append([]string{}, act.Content.Folders...), act.Content.Name), "/")))
+			fmt.Fprintf(&y, "    id: %s\n", yamlScalar(act.Content.Id))
+		}
+	}
+
+	writeRefs(&y, "scripts", op.Scripts)
+
+	if len(op.Properties) == 0 {
+		y.WriteString("properties: {}\n")
+		return y.String()
+	}
+	y.WriteString("properties:\n")
+	for _, item := range op.Properties {
+		value := item.Value
+		comment := ""
+		switch {
+		case item.Enc:
+			value = secrets.MASKED_VALUE
+			comment = "  # encrypted"
+		case secrets.IsSecretProperty(item.Key, item.Value):
+			value = secrets.MASKED_VALUE
+			comment = "  # secret"
+		default:
+			if ref := d.Resolve(item.Value); item.Value != "" && !ref.Missing {
+				comment = "  # " + ref.Path()
+			}
+		}
+		fmt.Fprintf(&y, "  %s: %s%s\n", yamlScalar(item.Key), yamlScalar(value), comment)
+	}
+	return y.String()
+}
+
+// writeRefs writes a list of entity paths.
+func writeRefs(y *strings.Builder, key string, refs []*Ref) {
+	if len(refs) == 0 {
+		fmt.Fprintf(y, "%s: []\n", key)
+		return
+	}
+	fmt.Fprintf(y, "%s:\n", key)
+	for _, ref := range refs {
+		fmt.Fprintf(y, "  - %s\n", yamlScalar(ref.Path()))
+	}
+}
+
+// yamlScalar returns a string as a plain scalar if it is unambiguous, otherwise double-quoted.
+// Go escape sequences are a subset of YAML double-quoted escapes.
+func yamlScalar(value string) string {
+	if plainScalar.MatchString(value) && !reservedScalar.MatchString(value) && !strings.HasSuffix(value, " ") {
+		return value
+	}
+	return strconv.Quote(value)
+}
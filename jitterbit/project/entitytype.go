@@ -117,13 +117,32 @@ func (et *EntityType) FindEntity(id string, rootDir string) (*Entity, string) {
 	return nil, dir
 }
 
+// FindEntityFolders searches for entity ID in EntityType and returns it with the names of its parent folders.
+func (et *EntityType) FindEntityFolders(id string) (*Entity, []string) {
+	// top-level entities
+	for idx := range et.Entities {
+		if et.Entities[idx].Id == id {
+			return &et.Entities[idx], []string{}
+		}
+	}
+
+	for idx := range et.Folders {
+		ent, names := et.Folders[idx].findEntityFolders(id)
+		if ent != nil {
+			return ent, names
+		}
+	}
+
+	return nil, nil
+}
+
 // RenameDirs substitutes folder IDs with real names.
 func (et *EntityType) RenameDirs(path string) error {
 	oldPath := ""
 	newPath := ""
 	for _, folder := range et.Folders {
 		oldPath = et.Dirs[folder.Id]
-		newPath = strings.Replace(oldPath, folder.Id, sanitizeFileName(folder.Name), 1)
+		newPath = strings.Replace(oldPath, folder.Id, SanitizeFileName(folder.Name), 1)
 		err := os.Rename(oldPath, newPath)
 		if err != nil {
 			return err
@@ -166,7 +185,7 @@ func (scripts *EntityType) CreateScripts(envPath string, targetPath string, sep
 			}
 			// example script name from Jitterbit's demo project:
 			// jb.sqlServer.table1-&gt;table2 [ETL_log]
-			saneName := sanitizeFileName(script.Header.Name)
+			saneName := SanitizeFileName(script.Header.Name)
 			outFilePath := fmt.Sprintf("%s%s%s.jb", scriptDir, sep, saneName)
 			outFile, err := os.Create(outFilePath)
 			if err != nil {
@@ -211,7 +230,7 @@ func (ops *EntityType) CreateOperations(envPath string, targetPath string, sep s
 			}
 
 			// copy xml
-			saneName := sanitizeFileName(op.Header.Name)
+			saneName := SanitizeFileName(op.Header.Name)
 			outFilePath := fmt.Sprintf("%s%s%s.xml", opDir, sep, saneName)
 			data, err := os.ReadFile(inFilePath)
 			if err != nil {
@@ -59,13 +59,30 @@ func (parent *Folder) findSubfolderEntity(dirs *map[string]string, id string) (*
 	return nil, ""
 }
 
+// findEntityFolders recursively searches for entity ID and returns it with the folder names leading to it.
+func (parent *Folder) findEntityFolders(id string) (*Entity, []string) {
+	for idx := range parent.Entities {
+		if parent.Entities[idx].Id == id {
+			return &parent.Entities[idx], []string{parent.Name}
+		}
+	}
+	for idx := range parent.Subfolders {
+		ent, names := parent.Subfolders[idx].findEntityFolders(id)
+		if ent != nil {
+			return ent, append([]string{parent.Name}, names...)
+		}
+	}
+	// not found
+	return nil, nil
+}
+
 // renameDirs substitutes subfolder IDs with real names.
 func (parent *Folder) renameDirs(dirs *map[string]string, path string) error {
 	oldPath := ""
 	newPath := ""
 	for _, folder := range parent.Subfolders {
 		oldPath = (*dirs)[folder.Id]
-		newPath = strings.Replace(oldPath, folder.Id, sanitizeFileName(folder.Name), 1)
+		newPath = strings.Replace(oldPath, folder.Id, SanitizeFileName(folder.Name), 1)
 		err := os.Rename(oldPath, newPath)
 		if err != nil {
 			return err
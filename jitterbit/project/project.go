@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 
+	"jbextractor/jitterbit/entity"
+
 	"golang.org/x/exp/slices"
 )
 
@@ -56,6 +58,57 @@ func (project *Project) GetEntityType(name string) *EntityType {
 	return et
 }
 
+// FindEntity searches all entity types for entity ID and returns its type, location and parent folder names.
+func (project *Project) FindEntity(id string) (*EntityType, *Entity, []string) {
+	for idx := range project.EntityTypes {
+		et := &project.EntityTypes[idx]
+		ent, folders := et.FindEntityFolders(id)
+		if ent != nil {
+			et.Type = et.Name
+			return et, ent, folders
+		}
+	}
+	return nil, nil, nil
+}
+
+// EntityFiles returns the paths of all entity files of the specified type in <environment>/Data/<type>.
+func (project *Project) EntityFiles(typeName string, sep string) ([]string, error) {
+	dataPath := fmt.Sprintf("%s%sData%s%s", project.EnvPath, sep, sep, typeName)
+	entries, err := os.ReadDir(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".xml") {
+			continue
+		}
+		paths = append(paths, fmt.Sprintf("%s%s%s", dataPath, sep, entry.Name()))
+	}
+
+	return paths, nil
+}
+
+// ParseEntities reads all entity files of the specified type from <environment>/Data/<type>.
+func (project *Project) ParseEntities(typeName string, sep string) ([]*entity.Entity, error) {
+	paths, err := project.EntityFiles(typeName, sep)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := []*entity.Entity{}
+	for _, path := range paths {
+		ent, err := entity.ParseEntity(path)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, ent)
+	}
+
+	return entities, nil
+}
+
 // updateDirPaths updates all paths with a restored directory name.
 func updateDirPaths(dirs *map[string]string, id string, name string) {
 	for dirId, path := range *dirs {
@@ -0,0 +1,55 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Resolves <TAG> references against an extracted project directory, e.g. for linting and running scripts.
+type DirResolver struct {
+	Root string
+	Sep  string
+}
+
+// ResolveTag checks whether the referenced script or operation file was extracted. Other entity types are not verified.
+func (resolver *DirResolver) ResolveTag(tag string) bool {
+	_, ok := resolver.TagPath(tag)
+	return ok
+}
+
+// TagPath returns the extracted file path of a <TAG> reference to a script or operation. Operations resolve to
+// the raw XML if it was extracted, else to the YAML definition or the runbook. The path is empty for existing
+// references to other entity types.
+func (resolver *DirResolver) TagPath(tag string) (string, bool) {
+	segments := strings.Split(tag, "/")
+	if len(segments) < 2 {
+		return "", false
+	}
+
+	var extensions []string
+	switch segments[0] {
+	case fmt.Sprintf("%ss", SCRIPT):
+		extensions = []string{".jb", ".js"}
+	case fmt.Sprintf("%ss", OPERATION):
+		extensions = []string{".xml", ".yaml", ".md"}
+	default:
+		return "", true
+	}
+
+	path := fmt.Sprintf("%s%s%s", resolver.Root, resolver.Sep, strings.TrimSuffix(segments[0], "s"))
+	for _, segment := range segments[1:] {
+		path = fmt.Sprintf("%s%s%s", path, resolver.Sep, SanitizeFileName(segment))
+	}
+	for _, ext := range extensions {
+		if _, err := os.Stat(path + ext); err == nil {
+			return path + ext, true
+		}
+	}
+	return "", false
+}
+
+// ResolveId always fails, the extractor substitutes all existing IDs with <TAG> paths.
+func (resolver *DirResolver) ResolveId(id string) (string, bool) {
+	return "", false
+}
@@ -4,8 +4,8 @@ import (
 	"strings"
 )
 
-// sanitizeFileName cleanses the entity names of special characters disallowed by file systems.
-func sanitizeFileName(name string) string {
+// SanitizeFileName cleanses the entity names of special characters disallowed by file systems.
+func SanitizeFileName(name string) string {
 	replacer := strings.NewReplacer(
 		"<", "_",
 		">", "_",
@@ -18,3 +18,20 @@ func sanitizeFileName(name string) string {
 		"\"", "_")
 	return replacer.Replace(name)
 }
+
+// Escapes Markdown syntax in names and values.
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"`", "\\`",
+	"*", "\\*",
+	"_", "\\_",
+	"[", "\\[",
+	"]", "\\]",
+	"|", "\\|",
+	"<", "&lt;",
+	">", "&gt;")
+
+// EscapeMarkdown escapes entity names and property values for Markdown text and tables.
+func EscapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
@@ -0,0 +1,299 @@
+package script
+
+// A syntax tree element with its source range.
+type Node interface {
+	Pos() Pos
+	End() Pos
+}
+
+// An expression. Jitterbit Script statements are expressions separated by semicolons.
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// A parsed script with all of its <trans> blocks.
+type Script struct {
+	Source   string
+	Blocks   []*Block
+	Comments []*Comment
+	// Source text outside of <trans> blocks.
+	Texts []*Text
+}
+
+// A <trans> block.
+type Block struct {
+	Open Pos
+	// Position of the </trans> tag, invalid for unterminated blocks.
+	Close Pos
+	// Position right after the last character of the block.
+	EndPos Pos
+	Body   []Expr
+	// Whether the last statement is followed by a semicolon.
+	Trailing bool
+	// Whether the block ends with a </trans> tag.
+	Closed bool
+}
+
+// A line or block comment.
+type Comment struct {
+	Start  Pos
+	EndPos Pos
+	Text   string
+}
+
+// Source text outside of <trans> blocks.
+type Text struct {
+	Start  Pos
+	EndPos Pos
+	Text   string
+}
+
+// Local variable reference.
+type Ident struct {
+	NamePos Pos
+	EndPos  Pos
+	Name    string
+}
+
+// Global variable reference, the name does not include the $ prefix.
+type GlobalVar struct {
+	NamePos Pos
+	EndPos  Pos
+	Name    string
+}
+
+// String literal.
+type StringLit struct {
+	ValuePos Pos
+	EndPos   Pos
+	// Quoted source text.
+	Raw string
+	// Unquoted value with escape sequences decoded.
+	Value string
+}
+
+// Numeric literal.
+type NumberLit struct {
+	ValuePos Pos
+	EndPos   Pos
+	Raw      string
+}
+
+// Boolean literal.
+type BoolLit struct {
+	ValuePos Pos
+	EndPos   Pos
+	Value    bool
+}
+
+// Function call, e.g. RunScript("sc.<id>") or If(cond, a, b).
+type Call struct {
+	Name   *Ident
+	Lparen Pos
+	Args   []Expr
+	Rparen Pos
+	EndPos Pos
+}
+
+// Binary operation.
+type Binary struct {
+	X     Expr
+	Op    TokenKind
+	OpPos Pos
+	Y     Expr
+}
+
+// Prefix operation, e.g. !x or -x.
+type Unary struct {
+	Op    TokenKind
+	OpPos Pos
+	X     Expr
+}
+
+// Increment or decrement, e.g. i++ or --i.
+type IncDec struct {
+	X      Expr
+	Op     TokenKind
+	OpPos  Pos
+	Prefix bool
+}
+
+// Assignment, e.g. $x = 1 or i += 2.
+type Assign struct {
+	Target Expr
+	Op     TokenKind
+	OpPos  Pos
+	Value  Expr
+}
+
+// Array literal, e.g. {1, 2, 3}.
+type Array struct {
+	Lbrace Pos
+	Elems  []Expr
+	Rbrace Pos
+	EndPos Pos
+}
+
+// Array element access, e.g. arr[0].
+type Index struct {
+	X      Expr
+	Lbrack Pos
+	Index  Expr
+	Rbrack Pos
+	EndPos Pos
+}
+
+// Parenthesized expression.
+type Paren struct {
+	Lparen Pos
+	X      Expr
+	Rparen Pos
+	EndPos Pos
+}
+
+// Semicolon-separated expressions used as a single function argument, e.g. If(cond, a = 1; b = 2).
+type Sequence struct {
+	Exprs []Expr
+	// Whether the last expression is followed by a semicolon.
+	Trailing bool
+}
+
+// Placeholder for an expression with syntax errors.
+type BadExpr struct {
+	From Pos
+	To   Pos
+}
+
+func (node *Script) Pos() Pos {
+	return Pos{Offset: 0, Line: 1, Col: 1}
+}
+
+func (node *Script) End() Pos {
+	return endOf(node.Source)
+}
+
+func (node *Block) Pos() Pos     { return node.Open }
+func (node *Block) End() Pos     { return node.EndPos }
+func (node *Comment) Pos() Pos   { return node.Start }
+func (node *Comment) End() Pos   { return node.EndPos }
+func (node *Text) Pos() Pos      { return node.Start }
+func (node *Text) End() Pos      { return node.EndPos }
+func (node *Ident) Pos() Pos     { return node.NamePos }
+func (node *Ident) End() Pos     { return node.EndPos }
+func (node *GlobalVar) Pos() Pos { return node.NamePos }
+func (node *GlobalVar) End() Pos { return node.EndPos }
+func (node *StringLit) Pos() Pos { return node.ValuePos }
+func (node *StringLit) End() Pos { return node.EndPos }
+func (node *NumberLit) Pos() Pos { return node.ValuePos }
+func (node *NumberLit) End() Pos { return node.EndPos }
+func (node *BoolLit) Pos() Pos   { return node.ValuePos }
+func (node *BoolLit) End() Pos   { return node.EndPos }
+func (node *Call) Pos() Pos      { return node.Name.Pos() }
+func (node *Call) End() Pos      { return node.EndPos }
+func (node *Binary) Pos() Pos    { return node.X.Pos() }
+func (node *Binary) End() Pos    { return node.Y.End() }
+func (node *Unary) Pos() Pos     { return node.OpPos }
+func (node *Unary) End() Pos     { return node.X.End() }
+func (node *Assign) Pos() Pos    { return node.Target.Pos() }
+func (node *Assign) End() Pos    { return node.Value.End() }
+func (node *Array) Pos() Pos     { return node.Lbrace }
+func (node *Array) End() Pos     { return node.EndPos }
+func (node *Index) Pos() Pos     { return node.X.Pos() }
+func (node *Index) End() Pos     { return node.EndPos }
+func (node *Paren) Pos() Pos     { return node.Lparen }
+func (node *Paren) End() Pos     { return node.EndPos }
+func (node *Sequence) Pos() Pos  { return node.Exprs[0].Pos() }
+func (node *Sequence) End() Pos  { return node.Exprs[len(node.Exprs)-1].End() }
+func (node *BadExpr) Pos() Pos   { return node.From }
+func (node *BadExpr) End() Pos   { return node.To }
+
+func (node *IncDec) Pos() Pos {
+	if node.Prefix {
+		return node.OpPos
+	}
+	return node.X.Pos()
+}
+
+func (node *IncDec) End() Pos {
+	if node.Prefix {
+		return node.X.End()
+	}
+	return Pos{Offset: node.OpPos.Offset + 2, Line: node.OpPos.Line, Col: node.OpPos.Col + 2}
+}
+
+func (*Ident) exprNode()     {}
+func (*GlobalVar) exprNode() {}
+func (*StringLit) exprNode() {}
+func (*NumberLit) exprNode() {}
+func (*BoolLit) exprNode()   {}
+func (*Call) exprNode()      {}
+func (*Binary) exprNode()    {}
+func (*Unary) exprNode()     {}
+func (*IncDec) exprNode()    {}
+func (*Assign) exprNode()    {}
+func (*Array) exprNode()     {}
+func (*Index) exprNode()     {}
+func (*Paren) exprNode()     {}
+func (*Sequence) exprNode()  {}
+func (*BadExpr) exprNode()   {}
+
+// Inspect traverses the node's subtree in depth-first order. Children are skipped if visit returns false.
+func Inspect(node Node, visit func(Node) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Script:
+		for _, block := range n.Blocks {
+			Inspect(block, visit)
+		}
+	case *Block:
+		for _, expr := range n.Body {
+			Inspect(expr, visit)
+		}
+	case *Call:
+		Inspect(n.Name, visit)
+		for _, arg := range n.Args {
+			Inspect(arg, visit)
+		}
+	case *Binary:
+		Inspect(n.X, visit)
+		Inspect(n.Y, visit)
+	case *Unary:
+		Inspect(n.X, visit)
+	case *IncDec:
+		Inspect(n.X, visit)
+	case *Assign:
+		Inspect(n.Target, visit)
+		Inspect(n.Value, visit)
+	case *Array:
+		for _, elem := range n.Elems {
+			Inspect(elem, visit)
+		}
+	case *Index:
+		Inspect(n.X, visit)
+		Inspect(n.Index, visit)
+	case *Paren:
+		Inspect(n.X, visit)
+	case *Sequence:
+		for _, expr := range n.Exprs {
+			Inspect(expr, visit)
+		}
+	}
+}
+
+// endOf returns the position right after the last character of the source.
+func endOf(src string) Pos {
+	pos := Pos{Offset: len(src), Line: 1, Col: 1}
+	for idx := 0; idx < len(src); idx++ {
+		if src[idx] == '\n' {
+			pos.Line++
+			pos.Col = 1
+		} else {
+			pos.Col++
+		}
+	}
+	return pos
+}
@@ -0,0 +1,354 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Formatting settings.
+type FormatOptions struct {
+	// Indentation unit.
+	Indent string
+	// Line separator, \n or \r\n.
+	LineEnding string
+	// Preferred maximum line length before calls are broken into multiple lines.
+	MaxWidth int
+}
+
+// DefaultFormatOptions returns 2-space indentation with LF line endings.
+func DefaultFormatOptions() FormatOptions {
+	return FormatOptions{Indent: "  ", LineEnding: "\n", MaxWidth: 100}
+}
+
+// Placeholder for line breaks inside string literals, which must not be normalized.
+const literalNewline = "\x00"
+
+// Functions whose arguments are code branches.
+var controlFunctions = map[string]bool{
+	"If":    true,
+	"Case":  true,
+	"While": true,
+	"Eval":  true,
+}
+
+// Pretty-printer state.
+type printer struct {
+	opts     FormatOptions
+	comments []*Comment
+	// Index of the next comment to print.
+	next int
+}
+
+// Format pretty-prints a script. Scripts with syntax errors are rejected.
+// The result is parsed again and compared with the original syntax tree to guarantee the same semantics.
+func Format(src string, opts FormatOptions) (string, error) {
+	parsed, errs := Parse(src)
+	if len(errs) > 0 {
+		return "", errs[0]
+	}
+
+	p := &printer{opts: opts, comments: parsed.Comments}
+	var out strings.Builder
+	texts := parsed.Texts
+	for _, block := range parsed.Blocks {
+		for len(texts) > 0 && texts[0].Start.Offset < block.Open.Offset {
+			out.WriteString(normalizeText(texts[0].Text))
+			texts = texts[1:]
+		}
+		out.WriteString(p.block(block))
+	}
+	for _, text := range texts {
+		out.WriteString(normalizeText(text.Text))
+	}
+
+	result := out.String()
+	if opts.LineEnding != "\n" {
+		result = strings.ReplaceAll(result, "\n", opts.LineEnding)
+	}
+	result = strings.ReplaceAll(result, literalNewline, "\n")
+
+	formatted, errs := Parse(result)
+	if len(errs) > 0 || !Equal(parsed, formatted) || len(formatted.Comments) != len(parsed.Comments) {
+		return "", errors.New("formatting would change the script semantics")
+	}
+	return result, nil
+}
+
+// normalizeText converts line endings of source text to LF.
+func normalizeText(text string) string {
+	return strings.ReplaceAll(text, "\r\n", "\n")
+}
+
+// indent returns the indentation of the nesting level.
+func (p *printer) indent(level int) string {
+	return strings.Repeat(p.opts.Indent, level)
+}
+
+// block prints a <trans> block with top-level statements at the first column.
+func (p *printer) block(block *Block) string {
+	body := p.statements(block.Body, block.Trailing, block.Close, 0)
+	if body == "" {
+		return "<trans>\n</trans>"
+	}
+	return fmt.Sprintf("<trans>\n%s\n</trans>", body)
+}
+
+// flush prints the comments preceding the position, each in a separate line.
+func (p *printer) flush(before Pos, level int) []string {
+	lines := []string{}
+	for p.next < len(p.comments) && p.comments[p.next].Start.Offset < before.Offset {
+		lines = append(lines, p.indent(level)+normalizeComment(p.comments[p.next].Text))
+		p.next++
+	}
+	return lines
+}
+
+// normalizeComment strips carriage returns from comment text.
+func normalizeComment(text string) string {
+	return strings.ReplaceAll(text, "\r", "")
+}
+
+// statements prints expressions separated by semicolons, one per line.
+func (p *printer) statements(stmts []Expr, trailing bool, end Pos, level int) string {
+	lines := []string{}
+	for idx, stmt := range stmts {
+		lines = append(lines, p.flush(stmt.Pos(), level)...)
+		line := p.indent(level) + p.expr(stmt, level)
+		if idx < len(stmts)-1 || trailing {
+			line += ";"
+		}
+		// same-line comment
+		stmtEnd := stmt.End()
+		if p.next < len(p.comments) && p.comments[p.next].Start.Line == stmtEnd.Line && p.comments[p.next].Start.Offset >= stmtEnd.Offset &&
+			(idx == len(stmts)-1 || p.comments[p.next].Start.Offset < stmts[idx+1].Pos().Offset) && p.comments[p.next].Start.Offset < end.Offset {
+			line += " " + normalizeComment(p.comments[p.next].Text)
+			p.next++
+		}
+		lines = append(lines, line)
+	}
+	if end.IsValid() {
+		lines = append(lines, p.flush(end, level)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// expr prints an expression. Continuation lines are indented, the first line is not.
+func (p *printer) expr(expr Expr, level int) string {
+	switch e := expr.(type) {
+	case *Ident:
+		return e.Name
+	case *GlobalVar:
+		return "$" + e.Name
+	case *StringLit:
+		return strings.ReplaceAll(e.Raw, "\n", literalNewline)
+	case *NumberLit:
+		return e.Raw
+	case *BoolLit:
+		return fmt.Sprintf("%t", e.Value)
+	case *Binary:
+		return fmt.Sprintf("%s %s %s", p.expr(e.X, level), e.Op, p.expr(e.Y, level))
+	case *Unary:
+		operand := p.expr(e.X, level)
+		if strings.HasPrefix(operand, "-") || strings.HasPrefix(operand, "+") {
+			return fmt.Sprintf("%s %s", e.Op, operand)
+		}
+		return e.Op.String() + operand
+	case *IncDec:
+		if e.Prefix {
+			return e.Op.String() + p.expr(e.X, level)
+		}
+		return p.expr(e.X, level) + e.Op.String()
+	case *Assign:
+		return fmt.Sprintf("%s %s %s", p.expr(e.Target, level), e.Op, p.expr(e.Value, level))
+	case *Array:
+		elems := []string{}
+		for _, elem := range e.Elems {
+			elems = append(elems, p.expr(elem, level))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(elems, ", "))
+	case *Index:
+		return fmt.Sprintf("%s[%s]", p.expr(e.X, level), p.expr(e.Index, level))
+	case *Paren:
+		return fmt.Sprintf("(%s)", p.expr(e.X, level))
+	case *Sequence:
+		return p.statements(e.Exprs, e.Trailing, Pos{}, level)
+	case *Call:
+		return p.call(e, level)
+	}
+	return ""
+}
+
+// call prints a function call, breaking control functions and long calls into multiple lines.
+func (p *printer) call(call *Call, level int) string {
+	if !p.isMultiline(call, level) {
+		args := []string{}
+		for _, arg := range call.Args {
+			args = append(args, p.expr(arg, level))
+		}
+		return fmt.Sprintf("%s(%s)", call.Name.Name, strings.Join(args, ", "))
+	}
+
+	var out strings.Builder
+	out.WriteString(call.Name.Name + "(")
+	args := call.Args
+	// keep the condition in the first line, e.g. If(cond,
+	if (call.Name.Name == "If" || call.Name.Name == "While") && !p.hasComments(call.Lparen, args[0].End()) {
+		if _, ok := args[0].(*Sequence); !ok {
+			out.WriteString(p.expr(args[0], level+1) + ",")
+			args = args[1:]
+		}
+	}
+
+	for idx, arg := range args {
+		out.WriteString("\n")
+		for _, comment := range p.flush(arg.Pos(), level+1) {
+			out.WriteString(comment + "\n")
+		}
+		if seq, ok := arg.(*Sequence); ok {
+			out.WriteString(p.statements(seq.Exprs, seq.Trailing, Pos{}, level+1))
+		} else {
+			out.WriteString(p.indent(level+1) + p.expr(arg, level+1))
+		}
+		if idx < len(args)-1 {
+			out.WriteString(",")
+		}
+	}
+	for _, comment := range p.flush(call.Rparen, level+1) {
+		out.WriteString("\n" + comment)
+	}
+	out.WriteString("\n" + p.indent(level) + ")")
+	return out.String()
+}
+
+// isMultiline decides whether the call is printed in multiple lines.
+func (p *printer) isMultiline(call *Call, level int) bool {
+	if len(call.Args) == 0 {
+		return false
+	}
+	if p.hasComments(call.Lparen, call.Rparen) {
+		return true
+	}
+
+	nested := false
+	for _, arg := range call.Args {
+		Inspect(arg, func(node Node) bool {
+			switch n := node.(type) {
+			case *Sequence:
+				nested = true
+			case *Call:
+				if controlFunctions[call.Name.Name] && controlFunctions[n.Name.Name] {
+					nested = true
+				}
+			}
+			return !nested
+		})
+	}
+	if nested {
+		return true
+	}
+
+	// measure the single-line form without consuming comments
+	saved := p.next
+	args := []string{}
+	for _, arg := range call.Args {
+		args = append(args, p.expr(arg, level))
+	}
+	p.next = saved
+	inline := fmt.Sprintf("%s(%s)", call.Name.Name, strings.Join(args, ", "))
+	return strings.Contains(inline, "\n") || len(p.indent(level))+len(inline) > p.opts.MaxWidth
+}
+
+// hasComments reports whether unprinted comments lie between the positions.
+func (p *printer) hasComments(from Pos, to Pos) bool {
+	for _, comment := range p.comments[p.next:] {
+		if comment.Start.Offset > from.Offset && comment.Start.Offset < to.Offset {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether two scripts have the same syntax trees and text outside of <trans> blocks, ignoring positions, comments and line endings.
+func Equal(a *Script, b *Script) bool {
+	if len(a.Texts) != len(b.Texts) {
+		return false
+	}
+	for idx := range a.Texts {
+		if normalizeText(a.Texts[idx].Text) != normalizeText(b.Texts[idx].Text) {
+			return false
+		}
+	}
+	return dump(a) == dump(b)
+}
+
+// dump returns a position-independent textual representation of the tree.
+func dump(node Node) string {
+	var out strings.Builder
+	var write func(node Node)
+	write = func(node Node) {
+		switch n := node.(type) {
+		case *Script:
+			for _, block := range n.Blocks {
+				write(block)
+			}
+			return
+		case *Block:
+			fmt.Fprintf(&out, "(block %t", n.Trailing)
+			for _, expr := range n.Body {
+				write(expr)
+			}
+		case *Ident:
+			fmt.Fprintf(&out, "(ident %s", n.Name)
+		case *GlobalVar:
+			fmt.Fprintf(&out, "(global %s", n.Name)
+		case *StringLit:
+			fmt.Fprintf(&out, "(string %q", n.Value)
+		case *NumberLit:
+			fmt.Fprintf(&out, "(number %s", n.Raw)
+		case *BoolLit:
+			fmt.Fprintf(&out, "(bool %t", n.Value)
+		case *Call:
+			fmt.Fprintf(&out, "(call %s", n.Name.Name)
+			for _, arg := range n.Args {
+				write(arg)
+			}
+		case *Binary:
+			fmt.Fprintf(&out, "(binary %s", n.Op)
+			write(n.X)
+			write(n.Y)
+		case *Unary:
+			fmt.Fprintf(&out, "(unary %s", n.Op)
+			write(n.X)
+		case *IncDec:
+			fmt.Fprintf(&out, "(incdec %s %t", n.Op, n.Prefix)
+			write(n.X)
+		case *Assign:
+			fmt.Fprintf(&out, "(assign %s", n.Op)
+			write(n.Target)
+			write(n.Value)
+		case *Array:
+			out.WriteString("(array")
+			for _, elem := range n.Elems {
+				write(elem)
+			}
+		case *Index:
+			out.WriteString("(index")
+			write(n.X)
+			write(n.Index)
+		case *Paren:
+			out.WriteString("(paren")
+			write(n.X)
+		case *Sequence:
+			fmt.Fprintf(&out, "(sequence %t", n.Trailing)
+			for _, expr := range n.Exprs {
+				write(expr)
+			}
+		default:
+			out.WriteString("(bad")
+		}
+		out.WriteString(")")
+	}
+	write(node)
+	return out.String()
+}
@@ -0,0 +1,72 @@
+package script
+
+import (
+	"sort"
+	"strings"
+)
+
+// A global variable occurrence in a script.
+type GlobalAccess struct {
+	// Variable name without the $ sign.
+	Name string
+	Pos  Pos
+	// Compound assignments and increments both read and write the variable.
+	Read  bool
+	Write bool
+}
+
+// GlobalAccesses returns all global variable reads and writes in source order,
+// including variables accessed by a literal name with Get and Set.
+func GlobalAccesses(parsed *Script) []*GlobalAccess {
+	// nodes which are only written to
+	writes := make(map[Node]bool)
+	// nodes which are read and written
+	updates := make(map[Node]bool)
+	accesses := []*GlobalAccess{}
+	Inspect(parsed, func(node Node) bool {
+		switch n := node.(type) {
+		case *Assign:
+			if n.Op == ASSIGN {
+				writes[n.Target] = true
+			} else {
+				updates[n.Target] = true
+			}
+		case *IncDec:
+			updates[n.X] = true
+		case *Call:
+			if len(n.Args) == 0 {
+				return true
+			}
+			lit, ok := n.Args[0].(*StringLit)
+			if !ok {
+				return true
+			}
+			// the $ sign is optional in Get and Set
+			name := strings.TrimPrefix(lit.Value, "$")
+			switch n.Name.Name {
+			case "Get":
+				accesses = append(accesses, &GlobalAccess{Name: name, Pos: lit.Pos(), Read: true})
+			case "Set":
+				accesses = append(accesses, &GlobalAccess{Name: name, Pos: lit.Pos(), Write: true})
+			}
+		}
+		return true
+	})
+
+	Inspect(parsed, func(node Node) bool {
+		if n, ok := node.(*GlobalVar); ok {
+			accesses = append(accesses, &GlobalAccess{
+				Name:  n.Name,
+				Pos:   n.Pos(),
+				Read:  !writes[n],
+				Write: writes[n] || updates[n],
+			})
+		}
+		return true
+	})
+
+	sort.SliceStable(accesses, func(i, j int) bool {
+		return accesses[i].Pos.Offset < accesses[j].Pos.Offset
+	})
+	return accesses
+}
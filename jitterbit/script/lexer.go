@@ -0,0 +1,321 @@
+package script
+
+import (
+	"strings"
+)
+
+const (
+	transOpenTag  = "<trans>"
+	transCloseTag = "</trans>"
+)
+
+// Jitterbit Script tokenizer. Source text outside of <trans> blocks is emitted as TEXT tokens.
+type Lexer struct {
+	src    string
+	offset int
+	line   int
+	col    int
+	// Whether the lexer is inside a <trans> block.
+	inCode bool
+	Errors []*Error
+}
+
+// NewLexer creates a lexer for a complete script with <trans> blocks.
+func NewLexer(src string) *Lexer {
+	return &Lexer{src: src, line: 1, col: 1}
+}
+
+// NewCodeLexer creates a lexer for a bare code snippet without <trans> tags, e.g. a mapping expression.
+func NewCodeLexer(src string) *Lexer {
+	lexer := NewLexer(src)
+	lexer.inCode = true
+	return lexer
+}
+
+// Tokenize returns all tokens of the script including comments, ending with EOF.
+func Tokenize(src string) ([]Token, []*Error) {
+	lexer := NewLexer(src)
+	tokens := []Token{}
+	for {
+		tok := lexer.Next()
+		tokens = append(tokens, tok)
+		if tok.Kind == EOF {
+			return tokens, lexer.Errors
+		}
+	}
+}
+
+// pos returns the current position.
+func (lexer *Lexer) pos() Pos {
+	return Pos{Offset: lexer.offset, Line: lexer.line, Col: lexer.col}
+}
+
+// peek returns the byte at the relative offset or 0 past the end.
+func (lexer *Lexer) peek(rel int) byte {
+	idx := lexer.offset + rel
+	if idx >= len(lexer.src) {
+		return 0
+	}
+	return lexer.src[idx]
+}
+
+// hasPrefix reports whether the remaining source starts with the prefix.
+func (lexer *Lexer) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(lexer.src[lexer.offset:], prefix)
+}
+
+// advance moves forward by n bytes tracking lines and columns.
+func (lexer *Lexer) advance(n int) {
+	for idx := 0; idx < n && lexer.offset < len(lexer.src); idx++ {
+		if lexer.src[lexer.offset] == '\n' {
+			lexer.line++
+			lexer.col = 1
+		} else {
+			lexer.col++
+		}
+		lexer.offset++
+	}
+}
+
+// error records a lexical error.
+func (lexer *Lexer) error(pos Pos, msg string) {
+	lexer.Errors = append(lexer.Errors, &Error{Pos: pos, Msg: msg})
+}
+
+// token creates a token spanning from start to the current position.
+func (lexer *Lexer) token(kind TokenKind, start Pos) Token {
+	return Token{
+		Kind: kind,
+		Text: lexer.src[start.Offset:lexer.offset],
+		Pos:  start,
+		End:  lexer.pos(),
+	}
+}
+
+// Next returns the next token.
+func (lexer *Lexer) Next() Token {
+	if !lexer.inCode {
+		return lexer.scanText()
+	}
+
+	lexer.skipWhitespace()
+	start := lexer.pos()
+	if lexer.offset >= len(lexer.src) {
+		return lexer.token(EOF, start)
+	}
+
+	if lexer.hasPrefix(transCloseTag) {
+		lexer.advance(len(transCloseTag))
+		lexer.inCode = false
+		return lexer.token(TRANS_CLOSE, start)
+	}
+
+	ch := lexer.peek(0)
+	switch {
+	case ch == '/' && lexer.peek(1) == '/':
+		return lexer.scanLineComment()
+	case ch == '/' && lexer.peek(1) == '*':
+		return lexer.scanBlockComment()
+	case ch == '"' || ch == '\'':
+		return lexer.scanString()
+	case ch == '$':
+		return lexer.scanGlobal()
+	case isDigit(ch) || (ch == '.' && isDigit(lexer.peek(1))):
+		return lexer.scanNumber()
+	case isIdentStart(ch):
+		for isIdentPart(lexer.peek(0)) {
+			lexer.advance(1)
+		}
+		return lexer.token(IDENT, start)
+	}
+
+	return lexer.scanOperator()
+}
+
+// scanText consumes source text up to the next <trans> tag.
+func (lexer *Lexer) scanText() Token {
+	start := lexer.pos()
+	if lexer.offset >= len(lexer.src) {
+		return lexer.token(EOF, start)
+	}
+
+	if lexer.hasPrefix(transOpenTag) {
+		lexer.advance(len(transOpenTag))
+		lexer.inCode = true
+		return lexer.token(TRANS_OPEN, start)
+	}
+
+	end := strings.Index(lexer.src[lexer.offset:], transOpenTag)
+	if end < 0 {
+		end = len(lexer.src) - lexer.offset
+	}
+	lexer.advance(end)
+	return lexer.token(TEXT, start)
+}
+
+// skipWhitespace consumes spaces, tabs and line breaks.
+func (lexer *Lexer) skipWhitespace() {
+	for lexer.offset < len(lexer.src) {
+		switch lexer.src[lexer.offset] {
+		case ' ', '\t', '\r', '\n', '\f', '\v':
+			lexer.advance(1)
+		default:
+			return
+		}
+	}
+}
+
+// scanLineComment consumes a // comment. A </trans> tag terminates the comment.
+func (lexer *Lexer) scanLineComment() Token {
+	start := lexer.pos()
+	for lexer.offset < len(lexer.src) && lexer.peek(0) != '\n' && !lexer.hasPrefix(transCloseTag) {
+		lexer.advance(1)
+	}
+	tok := lexer.token(COMMENT, start)
+	tok.Text = strings.TrimRight(tok.Text, "\r")
+	return tok
+}
+
+// scanBlockComment consumes a /* */ comment.
+func (lexer *Lexer) scanBlockComment() Token {
+	start := lexer.pos()
+	end := strings.Index(lexer.src[lexer.offset+2:], "*/")
+	if end < 0 {
+		lexer.error(start, "unterminated block comment")
+		lexer.advance(len(lexer.src) - lexer.offset)
+		return lexer.token(COMMENT, start)
+	}
+	lexer.advance(end + 4)
+	return lexer.token(COMMENT, start)
+}
+
+// scanString consumes a single or double quoted string literal and decodes its escape sequences.
+func (lexer *Lexer) scanString() Token {
+	start := lexer.pos()
+	quote := lexer.peek(0)
+	lexer.advance(1)
+
+	var value strings.Builder
+	for {
+		if lexer.offset >= len(lexer.src) {
+			lexer.error(start, "unterminated string literal")
+			break
+		}
+		ch := lexer.peek(0)
+		if ch == quote {
+			lexer.advance(1)
+			break
+		}
+		if ch == '\\' && lexer.offset+1 < len(lexer.src) {
+			value.WriteByte(unescape(lexer.peek(1)))
+			lexer.advance(2)
+			continue
+		}
+		value.WriteByte(ch)
+		lexer.advance(1)
+	}
+
+	tok := lexer.token(STRING, start)
+	tok.Value = value.String()
+	return tok
+}
+
+// unescape returns the character denoted by a backslash escape sequence.
+func unescape(ch byte) byte {
+	switch ch {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'f':
+		return '\f'
+	default:
+		return ch
+	}
+}
+
+// scanGlobal consumes a global variable reference, e.g. $jitterbit.target.file_name.
+func (lexer *Lexer) scanGlobal() Token {
+	start := lexer.pos()
+	lexer.advance(1)
+	for isIdentPart(lexer.peek(0)) || (lexer.peek(0) == '.' && isIdentPart(lexer.peek(1))) {
+		lexer.advance(1)
+	}
+
+	tok := lexer.token(GLOBAL, start)
+	tok.Value = strings.TrimPrefix(tok.Text, "$")
+	if tok.Value == "" {
+		lexer.error(start, "missing global variable name")
+		tok.Kind = ILLEGAL
+	}
+	return tok
+}
+
+// scanNumber consumes an integer or a floating point literal.
+func (lexer *Lexer) scanNumber() Token {
+	start := lexer.pos()
+	for isDigit(lexer.peek(0)) {
+		lexer.advance(1)
+	}
+	if lexer.peek(0) == '.' && isDigit(lexer.peek(1)) {
+		lexer.advance(1)
+		for isDigit(lexer.peek(0)) {
+			lexer.advance(1)
+		}
+	}
+	if ch := lexer.peek(0); ch == 'e' || ch == 'E' {
+		next := lexer.peek(1)
+		if isDigit(next) || ((next == '+' || next == '-') && isDigit(lexer.peek(2))) {
+			lexer.advance(2)
+			for isDigit(lexer.peek(0)) {
+				lexer.advance(1)
+			}
+		}
+	}
+	return lexer.token(NUMBER, start)
+}
+
+// Operators ordered so that longer symbols are matched first.
+var operators = []struct {
+	text string
+	kind TokenKind
+}{
+	{"&&", AND}, {"||", OR}, {"==", EQ}, {"!=", NEQ}, {"<=", LEQ}, {">=", GEQ},
+	{"+=", ADD_ASSIGN}, {"-=", SUB_ASSIGN}, {"*=", MUL_ASSIGN}, {"/=", DIV_ASSIGN},
+	{"++", INC}, {"--", DEC},
+	{"&", AND}, {"|", OR}, {"=", ASSIGN}, {"<", LT}, {">", GT}, {"!", NOT},
+	{"+", ADD}, {"-", SUB}, {"*", MUL}, {"/", DIV}, {"%", MOD}, {"^", POW},
+	{"(", LPAREN}, {")", RPAREN}, {"{", LBRACE}, {"}", RBRACE}, {"[", LBRACKET}, {"]", RBRACKET},
+	{",", COMMA}, {";", SEMICOLON},
+}
+
+// scanOperator consumes an operator or punctuation.
+func (lexer *Lexer) scanOperator() Token {
+	start := lexer.pos()
+	for _, op := range operators {
+		if lexer.hasPrefix(op.text) {
+			lexer.advance(len(op.text))
+			return lexer.token(op.kind, start)
+		}
+	}
+
+	lexer.advance(1)
+	lexer.error(start, "unexpected character "+strings.TrimSpace(lexer.src[start.Offset:lexer.offset]))
+	return lexer.token(ILLEGAL, start)
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch >= 0x80
+}
+
+func isIdentPart(ch byte) bool {
+	return isIdentStart(ch) || isDigit(ch)
+}
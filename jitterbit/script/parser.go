@@ -0,0 +1,350 @@
+package script
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Recursive descent parser of Jitterbit Script.
+type parser struct {
+	lexer  *Lexer
+	script *Script
+	tok    Token
+	errors []*Error
+}
+
+// Parse builds the syntax tree of a complete script with <trans> blocks.
+// The tree is returned alongside syntax errors, erroneous expressions are replaced with BadExpr nodes.
+func Parse(src string) (*Script, []*Error) {
+	p := newParser(NewLexer(src), src)
+	for p.tok.Kind != EOF {
+		switch p.tok.Kind {
+		case TEXT:
+			p.script.Texts = append(p.script.Texts, &Text{Start: p.tok.Pos, EndPos: p.tok.End, Text: p.tok.Text})
+			p.next()
+		case TRANS_OPEN:
+			p.script.Blocks = append(p.script.Blocks, p.parseBlock())
+		default:
+			p.error(p.tok.Pos, fmt.Sprintf("unexpected %s", p.tok.Kind))
+			p.next()
+		}
+	}
+	return p.script, p.allErrors()
+}
+
+// ParseCode builds the syntax tree of a bare code snippet without <trans> tags as a single block.
+func ParseCode(src string) (*Script, []*Error) {
+	p := newParser(NewCodeLexer(src), src)
+	block := &Block{Open: p.tok.Pos, Closed: true}
+	block.Body, block.Trailing = p.parseStatements()
+	block.EndPos = p.tok.End
+	for p.tok.Kind != EOF {
+		p.error(p.tok.Pos, fmt.Sprintf("unexpected %s", p.tok.Kind))
+		p.next()
+	}
+	p.script.Blocks = append(p.script.Blocks, block)
+	return p.script, p.allErrors()
+}
+
+// newParser creates a parser positioned at the first non-comment token.
+func newParser(lexer *Lexer, src string) *parser {
+	p := &parser{
+		lexer:  lexer,
+		script: &Script{Source: src},
+	}
+	p.next()
+	return p
+}
+
+// allErrors returns the lexical and syntax errors ordered by position.
+func (p *parser) allErrors() []*Error {
+	errs := append(p.lexer.Errors, p.errors...)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Pos.Offset < errs[j].Pos.Offset })
+	return errs
+}
+
+// next advances to the next token, collecting comments on the way.
+func (p *parser) next() {
+	p.tok = p.lexer.Next()
+	for p.tok.Kind == COMMENT {
+		p.script.Comments = append(p.script.Comments, &Comment{Start: p.tok.Pos, EndPos: p.tok.End, Text: p.tok.Text})
+		p.tok = p.lexer.Next()
+	}
+}
+
+// error records a syntax error.
+func (p *parser) error(pos Pos, msg string) {
+	p.errors = append(p.errors, &Error{Pos: pos, Msg: msg})
+}
+
+// expect consumes a token of the specified kind and returns its position.
+func (p *parser) expect(kind TokenKind) (Pos, bool) {
+	pos := p.tok.Pos
+	if p.tok.Kind != kind {
+		p.error(pos, fmt.Sprintf("expected %s, found %s", kind, p.describe()))
+		return pos, false
+	}
+	p.next()
+	return pos, true
+}
+
+// describe returns a human-readable description of the current token.
+func (p *parser) describe() string {
+	switch p.tok.Kind {
+	case IDENT, NUMBER, STRING, GLOBAL:
+		return fmt.Sprintf("%s %s", p.tok.Kind, p.tok.Text)
+	default:
+		return p.tok.Kind.String()
+	}
+}
+
+// parseBlock parses a <trans> block up to its closing tag.
+func (p *parser) parseBlock() *Block {
+	block := &Block{Open: p.tok.Pos}
+	p.next()
+	block.Body, block.Trailing = p.parseStatements()
+
+	if p.tok.Kind == EOF {
+		p.error(block.Open, "unterminated <trans> block")
+		block.EndPos = p.tok.End
+		return block
+	}
+
+	block.Close = p.tok.Pos
+	block.EndPos = p.tok.End
+	block.Closed = true
+	p.next()
+	return block
+}
+
+// parseStatements parses semicolon-separated expressions.
+// Reports whether the last expression is followed by a semicolon.
+func (p *parser) parseStatements() ([]Expr, bool) {
+	body := []Expr{}
+	trailing := false
+	for {
+		for p.tok.Kind == SEMICOLON {
+			trailing = true
+			p.next()
+		}
+		if p.atBlockEnd() {
+			return body, trailing
+		}
+
+		body = append(body, p.parseExpr())
+		trailing = false
+		if p.tok.Kind == SEMICOLON {
+			continue
+		}
+		if p.atBlockEnd() {
+			return body, trailing
+		}
+
+		p.error(p.tok.Pos, fmt.Sprintf("missing ; before %s", p.describe()))
+		p.skipStatement()
+	}
+}
+
+// atBlockEnd reports whether the current token ends a block.
+func (p *parser) atBlockEnd() bool {
+	return p.tok.Kind == TRANS_CLOSE || p.tok.Kind == EOF
+}
+
+// skipStatement skips tokens up to the next statement separator or the block end.
+func (p *parser) skipStatement() {
+	for p.tok.Kind != SEMICOLON && !p.atBlockEnd() {
+		p.next()
+	}
+}
+
+// parseExpr parses an expression.
+func (p *parser) parseExpr() Expr {
+	return p.parseAssign()
+}
+
+// parseAssign parses a right-associative assignment.
+func (p *parser) parseAssign() Expr {
+	target := p.parseBinary(lowestPrecedence)
+	if !p.tok.Kind.IsAssignment() {
+		return target
+	}
+
+	op := p.tok
+	switch target.(type) {
+	case *Ident, *GlobalVar, *Index, *BadExpr:
+	default:
+		p.error(op.Pos, fmt.Sprintf("cannot assign with %s to a non-variable expression", op.Kind))
+	}
+	p.next()
+	return &Assign{Target: target, Op: op.Kind, OpPos: op.Pos, Value: p.parseAssign()}
+}
+
+const lowestPrecedence = 1
+
+// precedence returns the binding power of a binary operator or 0 for other tokens.
+func precedence(kind TokenKind) int {
+	switch kind {
+	case OR:
+		return 1
+	case AND:
+		return 2
+	case EQ, NEQ:
+		return 3
+	case LT, GT, LEQ, GEQ:
+		return 4
+	case ADD, SUB:
+		return 5
+	case MUL, DIV, MOD:
+		return 6
+	case POW:
+		return 7
+	}
+	return 0
+}
+
+// parseBinary parses left-associative binary operations with at least the given precedence.
+func (p *parser) parseBinary(minPrec int) Expr {
+	x := p.parseUnary()
+	for {
+		prec := precedence(p.tok.Kind)
+		if prec == 0 || prec < minPrec {
+			return x
+		}
+		op := p.tok
+		p.next()
+		y := p.parseBinary(prec + 1)
+		x = &Binary{X: x, Op: op.Kind, OpPos: op.Pos, Y: y}
+	}
+}
+
+// parseUnary parses prefix operations.
+func (p *parser) parseUnary() Expr {
+	switch p.tok.Kind {
+	case NOT, SUB, ADD:
+		op := p.tok
+		p.next()
+		return &Unary{Op: op.Kind, OpPos: op.Pos, X: p.parseUnary()}
+	case INC, DEC:
+		op := p.tok
+		p.next()
+		return &IncDec{Op: op.Kind, OpPos: op.Pos, X: p.parseUnary(), Prefix: true}
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix parses array indexing and postfix increments.
+func (p *parser) parsePostfix() Expr {
+	x := p.parsePrimary()
+	for {
+		switch p.tok.Kind {
+		case LBRACKET:
+			index := &Index{X: x, Lbrack: p.tok.Pos}
+			p.next()
+			index.Index = p.parseExpr()
+			index.Rbrack = p.tok.Pos
+			index.EndPos = p.tok.End
+			p.expect(RBRACKET)
+			x = index
+		case INC, DEC:
+			x = &IncDec{X: x, Op: p.tok.Kind, OpPos: p.tok.Pos}
+			p.next()
+		default:
+			return x
+		}
+	}
+}
+
+// parsePrimary parses operands, calls and grouping.
+func (p *parser) parsePrimary() Expr {
+	tok := p.tok
+	switch tok.Kind {
+	case IDENT:
+		p.next()
+		ident := &Ident{NamePos: tok.Pos, EndPos: tok.End, Name: tok.Text}
+		if p.tok.Kind == LPAREN {
+			return p.parseCall(ident)
+		}
+		if tok.Text == "true" || tok.Text == "false" {
+			return &BoolLit{ValuePos: tok.Pos, EndPos: tok.End, Value: tok.Text == "true"}
+		}
+		return ident
+	case GLOBAL:
+		p.next()
+		return &GlobalVar{NamePos: tok.Pos, EndPos: tok.End, Name: tok.Value}
+	case STRING:
+		p.next()
+		return &StringLit{ValuePos: tok.Pos, EndPos: tok.End, Raw: tok.Text, Value: tok.Value}
+	case NUMBER:
+		p.next()
+		return &NumberLit{ValuePos: tok.Pos, EndPos: tok.End, Raw: tok.Text}
+	case LPAREN:
+		p.next()
+		paren := &Paren{Lparen: tok.Pos, X: p.parseExpr()}
+		paren.Rparen = p.tok.Pos
+		paren.EndPos = p.tok.End
+		p.expect(RPAREN)
+		return paren
+	case LBRACE:
+		p.next()
+		array := &Array{Lbrace: tok.Pos}
+		array.Elems = p.parseList(RBRACE)
+		array.Rbrace = p.tok.Pos
+		array.EndPos = p.tok.End
+		p.expect(RBRACE)
+		return array
+	}
+
+	p.error(tok.Pos, fmt.Sprintf("expected expression, found %s", p.describe()))
+	if !p.atBlockEnd() && tok.Kind != SEMICOLON && tok.Kind != RPAREN && tok.Kind != COMMA && tok.Kind != RBRACE {
+		p.next()
+	}
+	return &BadExpr{From: tok.Pos, To: tok.End}
+}
+
+// parseCall parses a function call argument list.
+func (p *parser) parseCall(name *Ident) Expr {
+	call := &Call{Name: name, Lparen: p.tok.Pos}
+	p.next()
+	call.Args = p.parseList(RPAREN)
+	call.Rparen = p.tok.Pos
+	call.EndPos = p.tok.End
+	if _, ok := p.expect(RPAREN); !ok {
+		call.EndPos = call.Rparen
+	}
+	return call
+}
+
+// parseList parses comma-separated expressions up to the closing token.
+// Jitterbit Script allows multiple statements in a single argument, e.g. If(cond, a = 1; b = 2).
+func (p *parser) parseList(closing TokenKind) []Expr {
+	list := []Expr{}
+	if p.tok.Kind == closing {
+		return list
+	}
+	for {
+		list = append(list, p.parseArgument(closing))
+		if p.tok.Kind != COMMA {
+			return list
+		}
+		p.next()
+	}
+}
+
+// parseArgument parses a single list element, which may be a semicolon-separated sequence.
+func (p *parser) parseArgument(closing TokenKind) Expr {
+	x := p.parseExpr()
+	if p.tok.Kind != SEMICOLON {
+		return x
+	}
+
+	seq := &Sequence{Exprs: []Expr{x}}
+	for p.tok.Kind == SEMICOLON {
+		p.next()
+		if p.tok.Kind == COMMA || p.tok.Kind == closing || p.atBlockEnd() {
+			seq.Trailing = true
+			break
+		}
+		seq.Exprs = append(seq.Exprs, p.parseExpr())
+	}
+	return seq
+}
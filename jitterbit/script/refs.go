@@ -0,0 +1,174 @@
+package script
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A built-in function parameter taking an entity ID.
+type RefParam struct {
+	Index int
+	// Accepted entity types, e.g. Script or Source.
+	Types []string
+}
+
+// Built-in functions taking entity references, e.g. RunScript("sc.<id>").
+var RefFunctions = map[string][]RefParam{
+	"RunScript":             {{0, []string{"Script"}}},
+	"RunOperation":          {{0, []string{"Operation"}}},
+	"CancelOperation":       {{0, []string{"Operation"}}},
+	"CancelOperationChain":  {{0, []string{"Operation"}}},
+	"GetOperationQueue":     {{0, []string{"Operation"}}},
+	"RunPlugin":             {{0, []string{"PipelinePlugin"}}},
+	"SendEmailMessage":      {{0, []string{"EmailMessage"}}},
+	"ArchiveFile":           {{0, []string{"Source"}}, {1, []string{"Target"}}},
+	"DBCloseConnection":     {{0, []string{"Source", "Target"}}},
+	"DBExecute":             {{0, []string{"Source", "Target"}}},
+	"DBLoad":                {{0, []string{"Source"}}, {1, []string{"Target"}}},
+	"DBLookup":              {{0, []string{"Source", "Target"}}},
+	"DBLookupAll":           {{0, []string{"Source", "Target"}}},
+	"DBRollbackTransaction": {{0, []string{"Source", "Target"}}},
+	"DBCommitTransaction":   {{0, []string{"Source", "Target"}}},
+	"DeleteFile":            {{0, []string{"Source"}}},
+	"DeleteFiles":           {{0, []string{"Source"}}},
+	"DirList":               {{0, []string{"Source"}}},
+	"FileList":              {{0, []string{"Source"}}},
+	"ReadFile":              {{0, []string{"Source"}}},
+	"WriteFile":             {{0, []string{"Target"}}},
+	"FlushFile":             {{0, []string{"Target"}}},
+	"FlushAllFiles":         {{0, []string{"Target"}}},
+}
+
+var (
+	idRegex  = regexp.MustCompile(`^(?:([A-Za-z]+)\.)?([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$`)
+	tagRegex = regexp.MustCompile(`^<TAG>(.*)</TAG>$`)
+)
+
+// Entity reference passed to a built-in function.
+type Reference struct {
+	Call *Call
+	// Index of the referencing argument.
+	Arg int
+	// Accepted entity types.
+	Types []string
+	// String literal argument, nil for dynamic references.
+	Literal *StringLit
+	// Entity kind prefix, e.g. sc or op.
+	Prefix string
+	// Entity ID, empty for <TAG> references.
+	Id string
+	// Path of an already resolved reference, e.g. Scripts/Folder/Name.
+	Tag string
+	// Whether the argument is not a string literal and cannot be resolved statically.
+	Dynamic bool
+}
+
+// Pos returns the position of the referencing argument.
+func (ref *Reference) Pos() Pos {
+	if ref.Arg < len(ref.Call.Args) {
+		return ref.Call.Args[ref.Arg].Pos()
+	}
+	return ref.Call.Pos()
+}
+
+// FindReferences returns entity references of the script in source order.
+// Calls with missing arguments are skipped, literals that are neither IDs nor <TAG> paths are reported as dynamic.
+func FindReferences(script *Script) []*Reference {
+	refs := []*Reference{}
+	Inspect(script, func(node Node) bool {
+		call, ok := node.(*Call)
+		if !ok {
+			return true
+		}
+		params, ok := RefFunctions[call.Name.Name]
+		if !ok {
+			return true
+		}
+
+		for _, param := range params {
+			if param.Index >= len(call.Args) {
+				continue
+			}
+			ref := &Reference{Call: call, Arg: param.Index, Types: param.Types}
+			lit, ok := unparen(call.Args[param.Index]).(*StringLit)
+			if !ok {
+				ref.Dynamic = true
+				refs = append(refs, ref)
+				continue
+			}
+
+			ref.Literal = lit
+			ref.Prefix, ref.Id, ref.Tag, ref.Dynamic = ParseRefValue(lit.Value)
+			refs = append(refs, ref)
+		}
+		return true
+	})
+
+	return refs
+}
+
+// TagType returns the entity type of a <TAG> path, e.g. Script for Scripts/Folder/Name.
+func TagType(tag string) string {
+	root, _, _ := strings.Cut(tag, "/")
+	return strings.TrimSuffix(root, "s")
+}
+
+// ParseRefValue splits a reference literal value into the entity kind prefix and ID, or the <TAG> path.
+// Values which are neither are reported as dynamic.
+func ParseRefValue(value string) (prefix string, id string, tag string, dynamic bool) {
+	if m := idRegex.FindStringSubmatch(value); m != nil {
+		return m[1], m[2], "", false
+	}
+	if m := tagRegex.FindStringSubmatch(value); m != nil {
+		return "", "", m[1], false
+	}
+	return "", "", "", true
+}
+
+// unparen strips enclosing parentheses.
+func unparen(expr Expr) Expr {
+	for {
+		paren, ok := expr.(*Paren)
+		if !ok {
+			return expr
+		}
+		expr = paren.X
+	}
+}
+
+// A source text replacement.
+type Edit struct {
+	Pos  Pos
+	End  Pos
+	Text string
+}
+
+// ApplyEdits returns the source with non-overlapping edits applied.
+func ApplyEdits(src string, edits []Edit) string {
+	sorted := append([]Edit{}, edits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pos.Offset < sorted[j].Pos.Offset })
+
+	var result strings.Builder
+	last := 0
+	for _, edit := range sorted {
+		if edit.Pos.Offset < last {
+			continue
+		}
+		result.WriteString(src[last:edit.Pos.Offset])
+		result.WriteString(edit.Text)
+		last = edit.End.Offset
+	}
+	result.WriteString(src[last:])
+	return result.String()
+}
+
+// Quote returns a string literal with the value using the quote character of the original literal.
+func Quote(value string, original *StringLit) string {
+	quote := "\""
+	if original != nil && strings.HasPrefix(original.Raw, "'") {
+		quote = "'"
+	}
+	escaped := strings.NewReplacer("\\", "\\\\", quote, "\\"+quote).Replace(value)
+	return quote + escaped + quote
+}
@@ -0,0 +1,155 @@
+package script
+
+import (
+	"fmt"
+)
+
+// Lexical token category.
+type TokenKind int
+
+// Token kind identifier.
+const (
+	ILLEGAL TokenKind = iota
+	EOF
+	// Source text outside of <trans> blocks.
+	TEXT
+	TRANS_OPEN
+	TRANS_CLOSE
+	COMMENT
+	IDENT
+	// Global variable, e.g. $jitterbit.operation.name.
+	GLOBAL
+	STRING
+	NUMBER
+	LPAREN
+	RPAREN
+	LBRACE
+	RBRACE
+	LBRACKET
+	RBRACKET
+	COMMA
+	SEMICOLON
+	ASSIGN
+	ADD_ASSIGN
+	SUB_ASSIGN
+	MUL_ASSIGN
+	DIV_ASSIGN
+	INC
+	DEC
+	ADD
+	SUB
+	MUL
+	DIV
+	MOD
+	POW
+	EQ
+	NEQ
+	LT
+	GT
+	LEQ
+	GEQ
+	AND
+	OR
+	NOT
+)
+
+var tokenNames = map[TokenKind]string{
+	ILLEGAL:     "ILLEGAL",
+	EOF:         "EOF",
+	TEXT:        "TEXT",
+	TRANS_OPEN:  "<trans>",
+	TRANS_CLOSE: "</trans>",
+	COMMENT:     "COMMENT",
+	IDENT:       "IDENT",
+	GLOBAL:      "GLOBAL",
+	STRING:      "STRING",
+	NUMBER:      "NUMBER",
+	LPAREN:      "(",
+	RPAREN:      ")",
+	LBRACE:      "{",
+	RBRACE:      "}",
+	LBRACKET:    "[",
+	RBRACKET:    "]",
+	COMMA:       ",",
+	SEMICOLON:   ";",
+	ASSIGN:      "=",
+	ADD_ASSIGN:  "+=",
+	SUB_ASSIGN:  "-=",
+	MUL_ASSIGN:  "*=",
+	DIV_ASSIGN:  "/=",
+	INC:         "++",
+	DEC:         "--",
+	ADD:         "+",
+	SUB:         "-",
+	MUL:         "*",
+	DIV:         "/",
+	MOD:         "%",
+	POW:         "^",
+	EQ:          "==",
+	NEQ:         "!=",
+	LT:          "<",
+	GT:          ">",
+	LEQ:         "<=",
+	GEQ:         ">=",
+	AND:         "&&",
+	OR:          "||",
+	NOT:         "!",
+}
+
+// String returns the operator symbol or the kind name.
+func (kind TokenKind) String() string {
+	if name, ok := tokenNames[kind]; ok {
+		return name
+	}
+	return fmt.Sprintf("TokenKind(%d)", int(kind))
+}
+
+// Precedence returns the binding strength of a binary operator, 0 for other kinds.
+func (kind TokenKind) Precedence() int {
+	return precedence(kind)
+}
+
+// IsAssignment reports whether the kind is an assignment operator.
+func (kind TokenKind) IsAssignment() bool {
+	return kind >= ASSIGN && kind <= DIV_ASSIGN
+}
+
+// A location in the script source. Line and column numbers start at 1, columns count bytes.
+type Pos struct {
+	Offset int
+	Line   int
+	Col    int
+}
+
+// String returns the position in line:column format.
+func (pos Pos) String() string {
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Col)
+}
+
+// IsValid reports whether the position was set.
+func (pos Pos) IsValid() bool {
+	return pos.Line > 0
+}
+
+// A single lexical unit of Jitterbit Script.
+type Token struct {
+	Kind TokenKind
+	// Raw source text.
+	Text string
+	// Unquoted string literal value or global variable name without the $ prefix.
+	Value string
+	Pos   Pos
+	// Position right after the last character.
+	End Pos
+}
+
+// A syntax error with its location.
+type Error struct {
+	Pos Pos
+	Msg string
+}
+
+// Error returns the message prefixed with the position.
+func (err *Error) Error() string {
+	return fmt.Sprintf("%s: %s", err.Pos, err.Msg)
+}
@@ -0,0 +1,85 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+)
+
+// Accepted findings, e.g. test credentials or false positives.
+type Allowlist struct {
+	// Finding fingerprints.
+	Fingerprints []string `json:"fingerprints"`
+	// Regular expressions matched against the secret.
+	Values []string `json:"values"`
+	// Entity path patterns, e.g. Script/Tests/*.
+	Entities []string `json:"entities"`
+	// Ignored rules, e.g. high-entropy.
+	Rules  []string `json:"rules"`
+	values []*regexp.Regexp
+}
+
+// LoadAllowlist reads an allowlist JSON file, e.g. {"fingerprints": ["3f1c..."], "entities": ["Script/Tests/*"]}.
+func LoadAllowlist(filePath string) (*Allowlist, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var allowlist Allowlist
+	if err := json.Unmarshal(data, &allowlist); err != nil {
+		return nil, err
+	}
+	for _, value := range allowlist.Values {
+		regex, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("[LoadAllowlist] Invalid value pattern %q: %s", value, err.Error())
+		}
+		allowlist.values = append(allowlist.values, regex)
+	}
+	for _, pattern := range allowlist.Entities {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("[LoadAllowlist] Invalid entity pattern %q", pattern)
+		}
+	}
+	return &allowlist, nil
+}
+
+// Allows reports whether the finding is accepted.
+func (allowlist *Allowlist) Allows(finding *Finding) bool {
+	for _, rule := range allowlist.Rules {
+		if rule == finding.Rule {
+			return true
+		}
+	}
+	fingerprint := finding.Fingerprint()
+	for _, allowed := range allowlist.Fingerprints {
+		if allowed == fingerprint {
+			return true
+		}
+	}
+	for _, regex := range allowlist.values {
+		if regex.MatchString(finding.Secret) {
+			return true
+		}
+	}
+	for _, pattern := range allowlist.Entities {
+		if ok, _ := path.Match(pattern, finding.Path()); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the findings which are not accepted.
+func (allowlist *Allowlist) Filter(findings []*Finding) []*Finding {
+	result := []*Finding{}
+	for _, finding := range findings {
+		if !allowlist.Allows(finding) {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
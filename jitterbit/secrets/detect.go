@@ -0,0 +1,178 @@
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Finding rule identifier.
+const (
+	PRIVATE_KEY         string = "private-key"
+	AWS_ACCESS_KEY      string = "aws-access-key"
+	GITHUB_TOKEN        string = "github-token"
+	SLACK_TOKEN         string = "slack-token"
+	GOOGLE_API_KEY      string = "google-api-key"
+	STRIPE_KEY          string = "stripe-key"
+	JWT                 string = "jwt"
+	PASSWORD_ASSIGNMENT string = "password-assignment"
+	BASIC_AUTH_URL      string = "basic-auth-url"
+	HIGH_ENTROPY        string = "high-entropy"
+	PLAINTEXT_PROPERTY  string = "plaintext-property"
+)
+
+// A secret detector, the secret is the first non-empty capture group or the whole match.
+type detector struct {
+	rule  string
+	regex *regexp.Regexp
+}
+
+// Known token formats and credential patterns, checked in order.
+var detectors = []detector{
+	{PRIVATE_KEY, regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY-----`)},
+	{AWS_ACCESS_KEY, regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{GITHUB_TOKEN, regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{SLACK_TOKEN, regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{GOOGLE_API_KEY, regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{STRIPE_KEY, regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{16,}\b`)},
+	{JWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	// $password = "..." in scripts, "password": "..." in JSON
+	{PASSWORD_ASSIGNMENT, regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret)["']?\s*[:=]\s*(?:"([^"\r\n]{4,})"|'([^'\r\n]{4,})')`)},
+	// password=... in connection strings and query strings
+	{PASSWORD_ASSIGNMENT, regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret)=([^\s"'&;,<>()\[\]]{4,})`)},
+	{BASIC_AUTH_URL, regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.-]*://[^\s:/@"'<>]+:([^\s/@"'<>]+)@`)},
+}
+
+var (
+	// Candidates of random tokens.
+	entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_-]{20,}={0,2}`)
+	// Quoted string literals of Jitterbit Script and JavaScript.
+	stringLiteral = regexp.MustCompile(`"(?:[^"\\\r\n]|\\.)*"|'(?:[^'\\\r\n]|\\.)*'`)
+	uuidRegex     = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	hexRegex      = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	// Property keys holding credentials.
+	sensitiveKey = regexp.MustCompile(`(?i)password|passwd|pwd|secret|token|api[_-]?key|private[_-]?key|passphrase|credential`)
+)
+
+// Minimum Shannon entropy in bits per character of random tokens.
+const (
+	MIN_ENTROPY     = 4.0
+	MIN_HEX_ENTROPY = 3.0
+	// Hexadecimal strings shorter than this are treated as IDs.
+	MIN_HEX_LENGTH = 32
+)
+
+// A secret found in a text with its byte offset.
+type match struct {
+	rule   string
+	secret string
+	offset int
+}
+
+// entropy returns the Shannon entropy of the string in bits per character.
+func entropy(value string) float64 {
+	counts := make(map[rune]int)
+	for _, ch := range value {
+		counts[ch]++
+	}
+	total := float64(len([]rune(value)))
+	result := 0.0
+	for _, count := range counts {
+		p := float64(count) / total
+		result -= p * math.Log2(p)
+	}
+	return result
+}
+
+// isRandom reports whether an entropy candidate looks like a generated token rather than an ID or a name.
+func isRandom(value string) bool {
+	value = strings.TrimRight(value, "=")
+	if uuidRegex.MatchString(value) {
+		return false
+	}
+	if hexRegex.MatchString(value) {
+		return len(value) >= MIN_HEX_LENGTH && entropy(value) >= MIN_HEX_ENTROPY
+	}
+
+	hasUpper := strings.IndexFunc(value, func(ch rune) bool { return ch >= 'A' && ch <= 'Z' }) >= 0
+	hasLower := strings.IndexFunc(value, func(ch rune) bool { return ch >= 'a' && ch <= 'z' }) >= 0
+	hasDigit := strings.IndexFunc(value, func(ch rune) bool { return ch >= '0' && ch <= '9' }) >= 0
+	if !hasDigit || !(hasUpper || hasLower) {
+		return false
+	}
+	return entropy(value) >= MIN_ENTROPY
+}
+
+// isPlaceholder reports values which are variables, masks or templates rather than secrets.
+func isPlaceholder(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.ContainsAny(value[:1], "$[{<%") {
+		return true
+	}
+	if strings.Trim(value, value[:1]) == "" {
+		// e.g. ******** or xxxx
+		return true
+	}
+	switch strings.ToLower(value) {
+	case "password", "changeme", "secret", "null", "none", "true", "false", "undefined":
+		return true
+	}
+	return false
+}
+
+// scanText finds secrets in a text. Random tokens are searched in quoted string literals only if
+// literals is set, e.g. in scripts, otherwise in the whole text.
+func scanText(text string, literals bool) []match {
+	matches := []match{}
+	// byte ranges of found secrets, overlapping candidates are skipped
+	covered := [][2]int{}
+	overlaps := func(start, end int) bool {
+		for _, r := range covered {
+			if start < r[1] && end > r[0] {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, det := range detectors {
+		for _, loc := range det.regex.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			for group := 1; group*2 < len(loc); group++ {
+				if loc[group*2] >= 0 {
+					start, end = loc[group*2], loc[group*2+1]
+					break
+				}
+			}
+			secret := text[start:end]
+			// password=GetPassword() is a function call
+			if det.rule == PASSWORD_ASSIGNMENT && (isPlaceholder(secret) || strings.HasPrefix(text[end:], "(")) {
+				continue
+			}
+			if det.rule == BASIC_AUTH_URL && isPlaceholder(secret) {
+				continue
+			}
+			if overlaps(start, end) {
+				continue
+			}
+			covered = append(covered, [2]int{start, end})
+			matches = append(matches, match{det.rule, secret, start})
+		}
+	}
+
+	regions := [][]int{{0, len(text)}}
+	if literals {
+		regions = stringLiteral.FindAllStringIndex(text, -1)
+	}
+	for _, region := range regions {
+		for _, loc := range entropyCandidate.FindAllStringIndex(text[region[0]:region[1]], -1) {
+			start, end := region[0]+loc[0], region[0]+loc[1]
+			if overlaps(start, end) || !isRandom(text[start:end]) {
+				continue
+			}
+			covered = append(covered, [2]int{start, end})
+			matches = append(matches, match{HIGH_ENTROPY, text[start:end], start})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,199 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/entity"
+	jbproj "jbextractor/jitterbit/project"
+)
+
+// A likely hardcoded secret.
+type Finding struct {
+	Rule     string
+	EntityId string
+	Entity   string
+	Type     string
+	// Slash-separated parent folder names.
+	Folder string
+	// Key of the entity property containing the secret, empty for script and operation content.
+	Property string
+	// Line within the script, or within the entity file for operations and properties.
+	Line int
+	// Detected secret, use Redacted for reports.
+	Secret string
+}
+
+// Path returns the entity path, e.g. Script/Folder/Name.
+func (f *Finding) Path() string {
+	if f.Folder == "" {
+		return f.Type + "/" + f.Entity
+	}
+	return f.Type + "/" + f.Folder + "/" + f.Entity
+}
+
+// Redacted returns the secret with all but its first characters masked.
+func (f *Finding) Redacted() string {
+	runes := []rune(f.Secret)
+	if len(runes) <= 8 {
+		return "****"
+	}
+	return string(runes[:4]) + "****"
+}
+
+// Fingerprint identifies the finding independent of its line, e.g. for allowlists.
+func (f *Finding) Fingerprint() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{f.Rule, f.EntityId, f.Property, f.Secret}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Scan searches script bodies, operation XML and entity properties of all entity types for hardcoded secrets.
+// Encrypted properties are skipped.
+func Scan(project *jbproj.Project, sep string) ([]*Finding, error) {
+	findings := []*Finding{}
+	for _, et := range project.EntityTypes {
+		paths, err := project.EntityFiles(et.Name, sep)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			found, err := scanEntity(project, et.Name, path)
+			if err != nil {
+				return nil, err
+			}
+			findings = append(findings, found...)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path() != findings[j].Path() {
+			return findings[i].Path() < findings[j].Path()
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
+
+// scanEntity scans a single entity file.
+func scanEntity(project *jbproj.Project, typeName string, path string) ([]*Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	base := Finding{Type: typeName}
+	findings := []*Finding{}
+	add := func(matches []match, text string, property string, lineOffset int) {
+		for _, m := range matches {
+			finding := base
+			finding.Rule = m.rule
+			finding.Secret = m.secret
+			finding.Property = property
+			finding.Line = lineOffset + strings.Count(text[:m.offset], "\n")
+			findings = append(findings, &finding)
+		}
+	}
+
+	// element names from the root
+	stack := []string{}
+	var script strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		line, _ := decoder.InputPos()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			switch {
+			case t.Name.Local == "Header":
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "ID":
+						base.EntityId = attr.Value
+					case "Name":
+						base.Entity = attr.Value
+					}
+				}
+			case t.Name.Local == "Item" && entity.AttrValue(t, "enc") != "true":
+				add(scanProperty(entity.AttrValue(t, "key"), entity.AttrValue(t, "value")), "", entity.AttrValue(t, "key"), line)
+			case typeName == jbproj.OPERATION:
+				for _, attr := range t.Attr {
+					add(scanText(attr.Value, false), attr.Value, "", line)
+				}
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			switch {
+			case typeName == jbproj.SCRIPT && strings.Join(stack, "/") == "Entity/konga.string":
+				script.Write(t)
+			case typeName == jbproj.OPERATION:
+				add(scanText(string(t), false), string(t), "", line)
+			}
+		}
+	}
+	// lines within the script as in the extracted file
+	add(scanText(script.String(), true), script.String(), "", 1)
+
+	if _, ent, folders := project.FindEntity(base.EntityId); ent != nil {
+		base.Entity = ent.Name
+		base.Folder = strings.Join(folders, "/")
+	}
+	for _, finding := range findings {
+		finding.EntityId, finding.Entity, finding.Folder = base.EntityId, base.Entity, base.Folder
+	}
+	return findings, nil
+}
+
+// Shown instead of encrypted and secret property values.
+const MASKED_VALUE = "********"
+
+// MaskProperty returns the property value for reports and documentation, encrypted and secret values are masked.
+func MaskProperty(key string, value string, enc bool) (string, bool) {
+	if enc || IsSecretProperty(key, value) {
+		return MASKED_VALUE, true
+	}
+	return value, false
+}
+
+// IsSecretProperty reports whether an unencrypted property value contains a likely secret.
+func IsSecretProperty(key string, value string) bool {
+	return len(scanProperty(key, value)) > 0
+}
+
+// IsSensitiveKey reports whether a property or parameter name usually holds a credential, e.g. api_key.
+func IsSensitiveKey(key string) bool {
+	return sensitiveKey.MatchString(key)
+}
+
+// scanProperty checks an unencrypted Item value, sensitive keys with literal values are always reported.
+func scanProperty(key string, value string) []match {
+	matches := scanText(value, false)
+	if len(matches) == 0 && sensitiveKey.MatchString(key) && !isPlaceholder(value) {
+		matches = append(matches, match{PLAINTEXT_PROPERTY, value, 0})
+	}
+	for idx := range matches {
+		// the finding line is the Item line
+		matches[idx].offset = 0
+	}
+	return matches
+}
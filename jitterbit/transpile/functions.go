@@ -0,0 +1,192 @@
+package transpile
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Converts JavaScript argument expressions to an equivalent expression, false if the arguments are not supported.
+type functionFunc func(args []string) (string, bool)
+
+// Pattern of operands which need no parentheses and can be evaluated twice.
+var simpleOperand = regexp.MustCompile(`^([A-Za-z_$][\w$]*|-?[0-9.]+|"([^"\\]|\\.)*")$`)
+
+// Pattern of positive integer literals.
+var positiveInt = regexp.MustCompile(`^[1-9][0-9]*$`)
+
+// Pattern of dotted function names.
+var functionName = regexp.MustCompile(`^[\w$.]+$`)
+
+// operand wraps complex expressions in parentheses, e.g. before a member access.
+func operand(expr string) string {
+	if simpleOperand.MatchString(expr) || isCall(expr) {
+		return expr
+	}
+	return "(" + expr + ")"
+}
+
+// isCall reports whether the expression is a single call with balanced parentheses, e.g. Jitterbit.GetVar("$x").
+func isCall(expr string) bool {
+	open := strings.Index(expr, "(")
+	if open <= 0 || !strings.HasSuffix(expr, ")") || !functionName.MatchString(expr[:open]) {
+		return false
+	}
+	depth := 0
+	inString := false
+	for idx := open; idx < len(expr); idx++ {
+		switch ch := expr[idx]; {
+		case inString && ch == '\\':
+			idx++
+		case ch == '"':
+			inString = !inString
+		case inString:
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+			if depth == 0 && idx != len(expr)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// method calls a String method on the first argument.
+func method(name string, count int) functionFunc {
+	return func(args []string) (string, bool) {
+		if len(args) != count {
+			return "", false
+		}
+		return fmt.Sprintf("String(%s).%s(%s)", args[0], name, strings.Join(args[1:], ", ")), true
+	}
+}
+
+// global calls a JavaScript function with the same arguments.
+func global(name string) functionFunc {
+	return func(args []string) (string, bool) {
+		return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", ")), true
+	}
+}
+
+// fixed returns a function accepting exactly count arguments formatted into a template.
+func fixed(count int, format string) functionFunc {
+	return func(args []string) (string, bool) {
+		if len(args) != count {
+			return "", false
+		}
+		values := []interface{}{}
+		for _, arg := range args {
+			values = append(values, arg)
+		}
+		return fmt.Sprintf(format, values...), true
+	}
+}
+
+// Jitterbit Script built-in functions with JavaScript equivalents, Jitterbit.* functions are provided by the engine.
+var functions = map[string]functionFunc{
+	// Array and dictionary
+	"AddToDict":  fixed(3, "(%s[%s] = %s)"),
+	"Array":      fixed(0, "[]"),
+	"Collection": fixed(0, "[]"),
+	"Dict":       fixed(0, "{}"),
+	"HasKey": func(args []string) (string, bool) {
+		if len(args) != 2 {
+			return "", false
+		}
+		return fmt.Sprintf("%s.hasOwnProperty(%s)", operand(args[0]), args[1]), true
+	},
+	"Length": func(args []string) (string, bool) {
+		if len(args) != 1 {
+			return "", false
+		}
+		return operand(args[0]) + ".length", true
+	},
+	// Conversion
+	"Bool":   fixed(1, "Boolean(%s)"),
+	"Double": fixed(1, "parseFloat(%s)"),
+	"Float":  fixed(1, "parseFloat(%s)"),
+	"Int":    fixed(1, "parseInt(%s, 10)"),
+	"Long":   fixed(1, "parseInt(%s, 10)"),
+	"String": fixed(1, "String(%s)"),
+	// Database and files
+	"DBExecute": global("Jitterbit.DbExecute"),
+	"DBLookup":  global("Jitterbit.DbLookup"),
+	"ReadFile":  global("Jitterbit.ReadFile"),
+	"WriteFile": global("Jitterbit.WriteFile"),
+	// Date and time
+	"Now":  fixed(0, "new Date()"),
+	"Now_": fixed(0, "new Date()"),
+	// Environment
+	"RunOperation":        global("RunOperation"),
+	"RunScript":           global("RunScript"),
+	"WriteToOperationLog": global("WriteToOperationLog"),
+	// General
+	"IfEmpty": func(args []string) (string, bool) {
+		if len(args) != 2 || !simpleOperand.MatchString(args[0]) {
+			return "", false
+		}
+		return fmt.Sprintf("(%s == null || %s === \"\" ? %s : %s)", args[0], args[0], args[1], args[0]), true
+	},
+	"IfNull": func(args []string) (string, bool) {
+		if len(args) != 2 || !simpleOperand.MatchString(args[0]) {
+			return "", false
+		}
+		return fmt.Sprintf("(%s == null ? %s : %s)", args[0], args[1], args[0]), true
+	},
+	"IsNull": fixed(1, "(%s == null)"),
+	"Null":   fixed(0, "null"),
+	// Logical
+	"Equal": fixed(2, "(%s == %s)"),
+	// Math
+	"Ceiling":    fixed(1, "Math.ceil(%s)"),
+	"Exp":        fixed(1, "Math.exp(%s)"),
+	"Floor":      fixed(1, "Math.floor(%s)"),
+	"Log":        fixed(1, "Math.log(%s)"),
+	"Log10":      fixed(1, "(Math.log(%s) / Math.LN10)"),
+	"Mod":        fixed(2, "(%s %% %s)"),
+	"Pow":        fixed(2, "Math.pow(%s, %s)"),
+	"RoundToInt": fixed(1, "Math.round(%s)"),
+	"Round": func(args []string) (string, bool) {
+		if len(args) == 1 {
+			return fmt.Sprintf("Math.round(%s)", args[0]), true
+		}
+		if len(args) != 2 {
+			return "", false
+		}
+		return fmt.Sprintf("(Math.round(%s * Math.pow(10, %s)) / Math.pow(10, %s))", operand(args[0]), args[1], args[1]), true
+	},
+	"Sqrt": fixed(1, "Math.sqrt(%s)"),
+	// String
+	"CountSubString": fixed(2, "(String(%s).split(%s).length - 1)"),
+	"DQuote":         fixed(1, "\"\\\"\" + %s + \"\\\"\""),
+	"Index":          method("indexOf", 2),
+	"Left":           fixed(2, "String(%s).substring(0, %s)"),
+	"LTrim":          fixed(1, "String(%s).replace(/^\\s+/, \"\")"),
+	"Mid":            method("substr", 3),
+	"Quote":          fixed(1, "\"'\" + %s + \"'\""),
+	"RegExReplace":   fixed(3, "String(%s).replace(new RegExp(%s, \"g\"), %s)"),
+	"Replace":        fixed(3, "String(%s).split(%s).join(%s)"),
+	"Right": func(args []string) (string, bool) {
+		if len(args) != 2 {
+			return "", false
+		}
+		// slice(-0) returns the whole string
+		switch {
+		case simpleOperand.MatchString(args[0]):
+			return fmt.Sprintf("String(%s).substring(String(%s).length - %s)", args[0], args[0], operand(args[1])), true
+		case positiveInt.MatchString(args[1]):
+			return fmt.Sprintf("String(%s).slice(-%s)", args[0], args[1]), true
+		case simpleOperand.MatchString(args[1]):
+			return fmt.Sprintf("(%s > 0 ? String(%s).slice(-%s) : \"\")", args[1], args[0], args[1]), true
+		}
+		return "", false
+	},
+	"RTrim":        fixed(1, "String(%s).replace(/\\s+$/, \"\")"),
+	"Split":        method("split", 2),
+	"StringLength": fixed(1, "String(%s).length"),
+	"ToLower":      method("toLowerCase", 1),
+	"ToUpper":      method("toUpperCase", 1),
+	"Trim":         method("trim", 1),
+}
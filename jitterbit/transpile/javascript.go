@@ -0,0 +1,477 @@
+package transpile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/script"
+)
+
+// Marker of code which needs a manual migration.
+const TODO_MARKER = "TODO(migration)"
+
+// A construct which could not be converted automatically.
+type Todo struct {
+	Pos script.Pos
+	Msg string
+}
+
+// Converted script with its migration notes.
+type Result struct {
+	Code  string
+	Todos []*Todo
+}
+
+// JavaScript reserved words which cannot be used as variable names.
+var reservedWords = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true,
+	"if": true, "import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"arguments": true, "eval": true, "undefined": true, "Jitterbit": true, "Math": true, "String": true,
+}
+
+// Converter state.
+type converter struct {
+	indent   string
+	todos    []*Todo
+	comments []*script.Comment
+	// Index of the next comment to print.
+	next int
+}
+
+// ToJavaScript converts a Jitterbit Script to JavaScript for the Jitterbit engine.
+// Global variables use Jitterbit.GetVar and Jitterbit.SetVar, unsupported constructs are kept with TODO markers.
+func ToJavaScript(src string) (*Result, error) {
+	parsed, errs := script.Parse(src)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	c := &converter{indent: "  ", comments: parsed.Comments}
+	lines := []string{}
+	if locals := localNames(parsed); len(locals) > 0 {
+		lines = append(lines, fmt.Sprintf("var %s;", strings.Join(locals, ", ")))
+	}
+	for _, text := range parsed.Texts {
+		if strings.TrimSpace(text.Text) != "" {
+			lines = append(lines, "// "+c.todo(text.Start, "text outside of <trans> blocks is not converted"))
+		}
+	}
+	for idx, block := range parsed.Blocks {
+		last := idx == len(parsed.Blocks)-1
+		lines = append(lines, c.statements(block.Body, 0, last)...)
+		lines = append(lines, c.flush(block.EndPos, 0)...)
+	}
+
+	sort.SliceStable(c.todos, func(i, j int) bool {
+		return c.todos[i].Pos.Offset < c.todos[j].Pos.Offset
+	})
+	return &Result{Code: strings.Join(lines, "\n") + "\n", Todos: c.todos}, nil
+}
+
+// localNames returns the sorted JavaScript names of all local variables.
+func localNames(parsed *script.Script) []string {
+	callees := make(map[script.Node]bool)
+	script.Inspect(parsed, func(node script.Node) bool {
+		if call, ok := node.(*script.Call); ok {
+			callees[call.Name] = true
+		}
+		return true
+	})
+
+	names := []string{}
+	seen := make(map[string]bool)
+	script.Inspect(parsed, func(node script.Node) bool {
+		if ident, ok := node.(*script.Ident); ok && !callees[ident] && !seen[ident.Name] {
+			seen[ident.Name] = true
+			names = append(names, jsName(ident.Name))
+		}
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
+// jsName renames local variables colliding with JavaScript reserved words.
+func jsName(name string) string {
+	if reservedWords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// jsQuote returns a double-quoted JavaScript string literal.
+func jsQuote(value string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\u2028', '\u2029':
+			fmt.Fprintf(&out, `\u%04x`, r)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&out, `\u%04x`, r)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+// todo records an unsupported construct and returns its marker text.
+func (c *converter) todo(pos script.Pos, format string, args ...interface{}) string {
+	msg := fmt.Sprintf(format, args...)
+	c.todos = append(c.todos, &Todo{Pos: pos, Msg: msg})
+	return fmt.Sprintf("%s: %s", TODO_MARKER, msg)
+}
+
+// todoComment returns an inline TODO comment.
+func (c *converter) todoComment(pos script.Pos, format string, args ...interface{}) string {
+	return fmt.Sprintf("/* %s */", c.todo(pos, format, args...))
+}
+
+// flush returns the comments preceding the position.
+func (c *converter) flush(before script.Pos, level int) []string {
+	lines := []string{}
+	for c.next < len(c.comments) && c.comments[c.next].Start.Offset < before.Offset {
+		lines = append(lines, strings.Repeat(c.indent, level)+strings.TrimRight(c.comments[c.next].Text, "\r"))
+		c.next++
+	}
+	return lines
+}
+
+// statements converts a statement list, the value of the last statement becomes the script result.
+func (c *converter) statements(stmts []script.Expr, level int, result bool) []string {
+	lines := []string{}
+	for idx, stmt := range stmts {
+		lines = append(lines, c.flush(stmt.Pos(), level)...)
+		lines = append(lines, c.statement(stmt, level, result && idx == len(stmts)-1)...)
+	}
+	return lines
+}
+
+// branch converts a control function argument to statements, the value of the last statement becomes the script result if result is set.
+func (c *converter) branch(expr script.Expr, level int, result bool) []string {
+	if seq, ok := expr.(*script.Sequence); ok {
+		return c.statements(seq.Exprs, level, result)
+	}
+	return c.statements([]script.Expr{expr}, level, result)
+}
+
+// block wraps statements in braces after a header, e.g. if (x) {.
+func (c *converter) block(header string, body []string, level int) []string {
+	lines := []string{strings.Repeat(c.indent, level) + header + " {"}
+	lines = append(lines, body...)
+	return append(lines, strings.Repeat(c.indent, level)+"}")
+}
+
+// statement converts an expression in statement position. Control functions become JavaScript statements.
+func (c *converter) statement(expr script.Expr, level int, result bool) []string {
+	prefix := strings.Repeat(c.indent, level)
+	switch e := expr.(type) {
+	case *script.Sequence:
+		return c.statements(e.Exprs, level, result)
+	case *script.Call:
+		if lines := c.controlStatement(e, level, result); lines != nil {
+			return lines
+		}
+	case *script.IncDec:
+		if !result {
+			return []string{prefix + c.incDec(e, false) + ";"}
+		}
+	}
+
+	if result {
+		return []string{fmt.Sprintf("%sSetScriptResult(%s);", prefix, c.expr(expr))}
+	}
+	return []string{prefix + c.expr(expr) + ";"}
+}
+
+// controlStatement converts control functions to statements, nil if the call is no control function.
+// If result is set, the branches pass their values to SetScriptResult, While returns nil to be converted as an expression.
+func (c *converter) controlStatement(call *script.Call, level int, result bool) []string {
+	prefix := strings.Repeat(c.indent, level)
+	args := call.Args
+	switch call.Name.Name {
+	case "If":
+		if len(args) < 2 || len(args) > 3 {
+			return nil
+		}
+		lines := c.block(fmt.Sprintf("if (%s)", c.expr(args[0])), c.branch(args[1], level+1, result), level)
+		if len(args) == 3 {
+			lines[len(lines)-1] += " else {"
+			lines = append(lines, c.branch(args[2], level+1, result)...)
+			lines = append(lines, prefix+"}")
+		}
+		return lines
+	case "Case":
+		if len(args) < 2 {
+			return nil
+		}
+		lines := []string{}
+		for idx := 0; idx+1 < len(args); idx += 2 {
+			header := fmt.Sprintf("if (%s)", c.expr(args[idx]))
+			if lit, ok := args[idx].(*script.BoolLit); ok && lit.Value && idx > 0 {
+				header = "else"
+			} else if idx > 0 {
+				header = "else " + header
+			}
+			body := c.branch(args[idx+1], level+1, result)
+			if idx == 0 {
+				lines = c.block(header, body, level)
+				continue
+			}
+			lines[len(lines)-1] += " " + header + " {"
+			lines = append(lines, body...)
+			lines = append(lines, prefix+"}")
+			if header == "else" {
+				break
+			}
+		}
+		return lines
+	case "While":
+		if len(args) < 2 || len(args) > 3 || result {
+			return nil
+		}
+		header := fmt.Sprintf("while (%s)", c.expr(args[0]))
+		if len(args) == 3 {
+			header += " " + c.todoComment(args[2].Pos(), "the While iteration limit is not enforced")
+		}
+		return c.block(header, c.branch(args[1], level+1, false), level)
+	case "Eval":
+		if len(args) != 2 {
+			return nil
+		}
+		lines := c.block("try", c.branch(args[0], level+1, result), level)
+		lines[len(lines)-1] += " catch (_error) {"
+		lines = append(lines, c.branch(args[1], level+1, result)...)
+		return append(lines, prefix+"}")
+	case "RaiseError":
+		if len(args) != 1 {
+			return nil
+		}
+		return []string{fmt.Sprintf("%sthrow new Error(%s);", prefix, c.expr(args[0]))}
+	case "ArgumentList":
+		return []string{prefix + "// " + c.todo(call.Pos(), "ArgumentList has no JavaScript equivalent, pass the values in global variables")}
+	}
+	return nil
+}
+
+// expr converts an expression.
+func (c *converter) expr(expr script.Expr) string {
+	switch e := expr.(type) {
+	case *script.StringLit:
+		return jsQuote(e.Value)
+	case *script.NumberLit:
+		return e.Raw
+	case *script.BoolLit:
+		return fmt.Sprintf("%t", e.Value)
+	case *script.Ident:
+		return jsName(e.Name)
+	case *script.GlobalVar:
+		return fmt.Sprintf("Jitterbit.GetVar(%s)", jsQuote("$"+e.Name))
+	case *script.Paren:
+		return "(" + c.expr(e.X) + ")"
+	case *script.Array:
+		elems := []string{}
+		for _, elem := range e.Elems {
+			elems = append(elems, c.expr(elem))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case *script.Index:
+		return fmt.Sprintf("%s[%s]", operand(c.expr(e.X)), c.expr(e.Index))
+	case *script.Sequence:
+		exprs := []string{}
+		for _, item := range e.Exprs {
+			exprs = append(exprs, c.expr(item))
+		}
+		return "(" + strings.Join(exprs, ", ") + ")"
+	case *script.Unary:
+		operand := c.child(e.X, 8, false)
+		if strings.HasPrefix(operand, "-") || strings.HasPrefix(operand, "+") {
+			return e.Op.String() + " " + operand
+		}
+		return e.Op.String() + operand
+	case *script.Binary:
+		if e.Op == script.POW {
+			return fmt.Sprintf("Math.pow(%s, %s)", c.expr(e.X), c.expr(e.Y))
+		}
+		prec := e.Op.Precedence()
+		return fmt.Sprintf("%s %s %s", c.child(e.X, prec, false), e.Op, c.child(e.Y, prec, true))
+	case *script.Assign:
+		return c.assign(e)
+	case *script.IncDec:
+		return c.incDec(e, true)
+	case *script.Call:
+		return c.call(e)
+	}
+	return c.todoComment(expr.Pos(), "expression could not be converted")
+}
+
+// child converts an operand, adding parentheses where JavaScript would bind it differently.
+func (c *converter) child(expr script.Expr, parent int, right bool) string {
+	text := c.expr(expr)
+	switch e := expr.(type) {
+	case *script.Binary:
+		prec := e.Op.Precedence()
+		if e.Op != script.POW && (prec < parent || (right && prec == parent)) {
+			return "(" + text + ")"
+		}
+	case *script.Assign:
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// compound returns the binary operator of a compound assignment or increment.
+func compound(op script.TokenKind) string {
+	switch op {
+	case script.ADD_ASSIGN, script.INC:
+		return "+"
+	case script.SUB_ASSIGN, script.DEC:
+		return "-"
+	case script.MUL_ASSIGN:
+		return "*"
+	case script.DIV_ASSIGN:
+		return "/"
+	}
+	return ""
+}
+
+// assign converts assignments, global variables are written with Jitterbit.SetVar.
+func (c *converter) assign(e *script.Assign) string {
+	value := c.expr(e.Value)
+	switch target := e.Target.(type) {
+	case *script.GlobalVar:
+		name := jsQuote("$" + target.Name)
+		if e.Op != script.ASSIGN {
+			value = fmt.Sprintf("Jitterbit.GetVar(%s) %s %s", name, compound(e.Op), c.child(e.Value, 5, true))
+		}
+		return fmt.Sprintf("Jitterbit.SetVar(%s, %s)", name, value)
+	case *script.Index:
+		if hasGlobalBase(target) {
+			return fmt.Sprintf("%s = %s %s", c.expr(target), value, c.todoComment(target.Pos(), "elements of global arrays must be written back with Jitterbit.SetVar"))
+		}
+	}
+	return fmt.Sprintf("%s %s %s", c.expr(e.Target), e.Op, value)
+}
+
+// hasGlobalBase reports whether an indexed expression is based on a global variable.
+func hasGlobalBase(expr script.Expr) bool {
+	for {
+		switch e := expr.(type) {
+		case *script.Index:
+			expr = e.X
+		case *script.Paren:
+			expr = e.X
+		case *script.GlobalVar:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// incDec converts increments, global variables are written with Jitterbit.SetVar. If the value is used,
+// global increments become an immediately invoked function returning the old or, for prefix operators, the new value.
+func (c *converter) incDec(e *script.IncDec, value bool) string {
+	if global, ok := e.X.(*script.GlobalVar); ok {
+		name := jsQuote("$" + global.Name)
+		if !value {
+			return fmt.Sprintf("Jitterbit.SetVar(%s, Jitterbit.GetVar(%s) %s 1)", name, name, compound(e.Op))
+		}
+		lines := []string{
+			fmt.Sprintf("%svar _old = Jitterbit.GetVar(%s);", c.indent, name),
+			fmt.Sprintf("%sJitterbit.SetVar(%s, _old %s 1);", c.indent, name, compound(e.Op)),
+			fmt.Sprintf("%sreturn _old;", c.indent),
+		}
+		if e.Prefix {
+			lines[2] = fmt.Sprintf("%sreturn _old %s 1;", c.indent, compound(e.Op))
+		}
+		return fmt.Sprintf("(function () {\n%s\n})()", strings.Join(lines, "\n"))
+	}
+	if e.Prefix {
+		return e.Op.String() + c.expr(e.X)
+	}
+	return c.expr(e.X) + e.Op.String()
+}
+
+// call converts a function call in expression position.
+func (c *converter) call(call *script.Call) string {
+	name := call.Name.Name
+	args := call.Args
+	switch name {
+	case "If":
+		if len(args) == 2 || len(args) == 3 {
+			otherwise := "null"
+			if len(args) == 3 {
+				otherwise = c.expr(args[2])
+			}
+			return fmt.Sprintf("(%s ? %s : %s)", c.expr(args[0]), c.expr(args[1]), otherwise)
+		}
+	case "Case":
+		if len(args) >= 2 {
+			parts := []string{}
+			for idx := 0; idx+1 < len(args); idx += 2 {
+				parts = append(parts, fmt.Sprintf("%s ? %s", c.expr(args[idx]), c.expr(args[idx+1])))
+			}
+			return fmt.Sprintf("(%s : null)", strings.Join(parts, " : "))
+		}
+	case "Eval":
+		// statements inside an immediately invoked function
+		if len(args) == 2 {
+			lines := c.block("try", []string{c.indent + c.indent + "return " + c.expr(args[0]) + ";"}, 1)
+			lines[len(lines)-1] += " catch (_error) {"
+			lines = append(lines, c.indent+c.indent+"return "+c.expr(args[1])+";", c.indent+"}")
+			return fmt.Sprintf("(function () {\n%s\n})()", strings.Join(lines, "\n"))
+		}
+	case "While", "RaiseError":
+		if lines := c.controlStatement(call, 1, false); lines != nil {
+			return fmt.Sprintf("(function () {\n%s\n})()", strings.Join(lines, "\n"))
+		}
+	case "Get":
+		if len(args) == 1 {
+			return fmt.Sprintf("Jitterbit.GetVar(%s)", c.varName(args[0]))
+		}
+	case "Set":
+		if len(args) == 2 {
+			return fmt.Sprintf("Jitterbit.SetVar(%s, %s)", c.varName(args[0]), c.expr(args[1]))
+		}
+	}
+
+	converted := []string{}
+	for _, arg := range args {
+		converted = append(converted, c.expr(arg))
+	}
+	if fn, ok := functions[name]; ok {
+		if code, ok := fn(converted); ok {
+			return code
+		}
+		return fmt.Sprintf("%s(%s) %s", name, strings.Join(converted, ", "), c.todoComment(call.Pos(), "%s with %d argument(s) has no JavaScript equivalent", name, len(args)))
+	}
+	return fmt.Sprintf("%s(%s) %s", name, strings.Join(converted, ", "), c.todoComment(call.Pos(), "%s has no JavaScript equivalent", name))
+}
+
+// varName returns the $-prefixed global variable name of a Get or Set argument.
+func (c *converter) varName(expr script.Expr) string {
+	if lit, ok := expr.(*script.StringLit); ok {
+		return jsQuote("$" + strings.TrimPrefix(lit.Value, "$"))
+	}
+	return fmt.Sprintf("\"$\" + %s", c.child(expr, 5, true))
+}
@@ -0,0 +1,51 @@
+package transpile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToJavaScriptScriptResult(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`<trans>ToUpper("a")</trans>`, `SetScriptResult(String("a").toUpperCase());`},
+		{`<trans>x = 1</trans>`, `SetScriptResult(x = 1);`},
+		{`<trans>$count++; x = 1</trans>`, "Jitterbit.SetVar(\"$count\", Jitterbit.GetVar(\"$count\") + 1);\nSetScriptResult(x = 1);"},
+		{`<trans>x = 1; ToUpper("a")</trans>`, "x = 1;\nSetScriptResult(String(\"a\").toUpperCase());"},
+		{`<trans>If(a, 1, 2)</trans>`, "if (a) {\n  SetScriptResult(1);\n} else {\n  SetScriptResult(2);\n}"},
+		{`<trans>Case(a, 1, true, x = 2; ToUpper("b"))</trans>`, "} else {\n  x = 2;\n  SetScriptResult(String(\"b\").toUpperCase());\n}"},
+	}
+	for _, c := range cases {
+		result, err := ToJavaScript(c.src)
+		if err != nil {
+			t.Fatalf("%s: %s", c.src, err)
+		}
+		if !strings.Contains(result.Code, c.want) {
+			t.Errorf("%s: got\n%s\nwant %s", c.src, result.Code, c.want)
+		}
+	}
+}
+
+func TestToJavaScriptFunctions(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`<trans>RunScript("<TAG>Scripts/Utils/helper</TAG>", 1); x = 1</trans>`, `RunScript("<TAG>Scripts/Utils/helper</TAG>", 1);`},
+		{`<trans>Right("abc", 0)</trans>`, `SetScriptResult(String("abc").substring(String("abc").length - 0));`},
+		{`<trans>Right("00" + n, 2)</trans>`, `SetScriptResult(String("00" + n).slice(-2));`},
+		{`<trans>Right("00" + s, n)</trans>`, `SetScriptResult((n > 0 ? String("00" + s).slice(-n) : ""));`},
+		{`<trans>RunOperation("<TAG>Operations/Orders/Sync</TAG>", false); x = 1</trans>`, `RunOperation("<TAG>Operations/Orders/Sync</TAG>", false);`},
+	}
+	for _, c := range cases {
+		result, err := ToJavaScript(c.src)
+		if err != nil {
+			t.Fatalf("%s: %s", c.src, err)
+		}
+		if !strings.Contains(result.Code, c.want) || strings.Contains(result.Code, "TODO") {
+			t.Errorf("%s: got\n%s\nwant %s", c.src, result.Code, c.want)
+		}
+	}
+}
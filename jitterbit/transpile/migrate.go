@@ -0,0 +1,108 @@
+package transpile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Conversion outcome of a single script file.
+type Migration struct {
+	// Script path relative to the extraction directory.
+	File string
+	// Written JavaScript path relative to the output directory, empty if the conversion failed.
+	Output string
+	Result *Result
+	Err    error
+}
+
+// Status returns converted, needs review or failed.
+func (m *Migration) Status() string {
+	switch {
+	case m.Err != nil:
+		return "failed"
+	case len(m.Result.Todos) > 0:
+		return "needs review"
+	}
+	return "converted"
+}
+
+// MigrateDir converts all Jitterbit Script files of an extraction directory to JavaScript files
+// with the same relative paths in the output directory.
+func MigrateDir(root string, out string) ([]*Migration, error) {
+	absOut, err := filepath.Abs(out)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []*Migration{}
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if abs, _ := filepath.Abs(path); d.IsDir() && abs == absOut {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".jb") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		migration := &Migration{File: filepath.ToSlash(rel)}
+		migrations = append(migrations, migration)
+		migration.Result, migration.Err = ToJavaScript(string(data))
+		if migration.Err != nil {
+			return nil
+		}
+
+		migration.Output = strings.TrimSuffix(migration.File, ".jb") + ".js"
+		target := filepath.Join(out, filepath.FromSlash(migration.Output))
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+		return os.WriteFile(target, []byte(migration.Result.Code), os.ModePerm)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+// Report returns a Markdown migration report with a summary table and the TODO items of each script.
+func Report(migrations []*Migration) string {
+	var out strings.Builder
+	out.WriteString("# JavaScript migration report\n\n")
+	out.WriteString("| Script | Status | TODOs |\n|---|---|---|\n")
+	for _, m := range migrations {
+		todos := 0
+		if m.Result != nil {
+			todos = len(m.Result.Todos)
+		}
+		fmt.Fprintf(&out, "| %s | %s | %d |\n", m.File, m.Status(), todos)
+	}
+
+	for _, m := range migrations {
+		fmt.Fprintf(&out, "\n## %s\n\n", m.File)
+		switch {
+		case m.Err != nil:
+			fmt.Fprintf(&out, "The script could not be converted: %s\n", m.Err.Error())
+		case len(m.Result.Todos) == 0:
+			fmt.Fprintf(&out, "Converted to `%s` without manual changes.\n", m.Output)
+		default:
+			fmt.Fprintf(&out, "Converted to `%s`, the following `%s` markers need manual changes:\n\n", m.Output, TODO_MARKER)
+			for _, todo := range m.Result.Todos {
+				fmt.Fprintf(&out, "- line %d: %s\n", todo.Pos.Line, todo.Msg)
+			}
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,196 @@
+package xref
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"jbextractor/jitterbit/entity"
+	jbproj "jbextractor/jitterbit/project"
+	"jbextractor/jitterbit/script"
+)
+
+// Global variable access location.
+type Location struct {
+	EntityId string
+	Entity   string
+	Type     string
+	// Slash-separated parent folder names.
+	Folder string
+	// Line within the script, or within the entity file for transformation mappings.
+	Line int
+}
+
+// A global variable with all its accesses.
+type Variable struct {
+	// Name without the $ sign.
+	Name   string
+	Writes []*Location
+	Reads  []*Location
+	// Project variable defining the global, nil if there is none.
+	Definition *Location
+}
+
+// System reports whether the variable is managed by the Jitterbit engine, e.g. $jitterbit.operation.name.
+func (v *Variable) System() bool {
+	return strings.HasPrefix(strings.ToLower(v.Name), "jitterbit.")
+}
+
+// Unwritten reports a variable which is read but neither written nor defined as a project variable.
+func (v *Variable) Unwritten() bool {
+	return !v.System() && len(v.Reads) > 0 && len(v.Writes) == 0 && v.Definition == nil
+}
+
+// Unread reports a variable which is written or defined but never read.
+func (v *Variable) Unread() bool {
+	return !v.System() && len(v.Reads) == 0 && (len(v.Writes) > 0 || v.Definition != nil)
+}
+
+// Cross-reference of global variables in an environment.
+type GlobalsReport struct {
+	// Variables sorted by name.
+	Variables []*Variable
+	byName    map[string]*Variable
+}
+
+// variable returns the report entry of a global, creating it if necessary.
+func (report *GlobalsReport) variable(name string) *Variable {
+	v, ok := report.byName[name]
+	if !ok {
+		v = &Variable{Name: name}
+		report.byName[name] = v
+		report.Variables = append(report.Variables, v)
+	}
+	return v
+}
+
+// Globals collects global variable reads and writes across all Jitterbit scripts and transformation mappings
+// and joins them with project variable definitions.
+func Globals(project *jbproj.Project, sep string) (*GlobalsReport, error) {
+	report := &GlobalsReport{byName: make(map[string]*Variable)}
+
+	scripts, err := parseEntities(project, jbproj.SCRIPT, sep)
+	if err != nil {
+		return nil, err
+	}
+	for _, ent := range scripts {
+		// JavaScript scripts access globals through Jitterbit.GetVar and Jitterbit.SetVar
+		if _, ok := JavaScriptCode(ent.KongaString); ok {
+			continue
+		}
+		report.addScript(*newLocation(project, ent), ent.KongaString, 0)
+	}
+
+	paths, err := entityFiles(project, jbproj.TRANSFORMATION, sep)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if err := report.addTransformation(project, path); err != nil {
+			return nil, err
+		}
+	}
+
+	variables, err := parseEntities(project, jbproj.VARIABLE, sep)
+	if err != nil {
+		return nil, err
+	}
+	for _, ent := range variables {
+		report.variable(strings.TrimPrefix(ent.Header.Name, "$")).Definition = newLocation(project, ent)
+	}
+
+	sort.Slice(report.Variables, func(i, j int) bool {
+		return report.Variables[i].Name < report.Variables[j].Name
+	})
+	return report, nil
+}
+
+// addScript records the global variable accesses of a script, lineOffset is added to the script lines.
+func (report *GlobalsReport) addScript(loc Location, src string, lineOffset int) {
+	parsed, _ := script.Parse(src)
+	for _, access := range script.GlobalAccesses(parsed) {
+		accessLoc := loc
+		accessLoc.Line = access.Pos.Line + lineOffset
+		v := report.variable(access.Name)
+		if access.Write {
+			v.Writes = append(v.Writes, &accessLoc)
+		}
+		if access.Read {
+			v.Reads = append(v.Reads, &accessLoc)
+		}
+	}
+}
+
+// addTransformation records the global variable accesses of all <trans> mappings in a transformation file.
+func (report *GlobalsReport) addTransformation(project *jbproj.Project, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	ent, err := entity.ParseEntity(path)
+	if err != nil {
+		return err
+	}
+	loc := *newLocation(project, ent)
+
+	// mapping formulas are stored as escaped element text or attribute values
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		line, _ := decoder.InputPos()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			if strings.Contains(string(t), "<trans>") {
+				report.addScript(loc, string(t), line-1)
+			}
+		case xml.StartElement:
+			for _, attr := range t.Attr {
+				if strings.Contains(attr.Value, "<trans>") {
+					report.addScript(loc, attr.Value, line-1)
+				}
+			}
+		}
+	}
+}
+
+// newLocation returns the entity location without a line.
+func newLocation(project *jbproj.Project, ent *entity.Entity) *Location {
+	loc := &Location{EntityId: ent.Header.Id, Entity: ent.Header.Name, Type: ent.Type}
+	et, found, folders := project.FindEntity(ent.Header.Id)
+	if found != nil {
+		loc.Entity = found.Name
+		loc.Type = et.Type
+		loc.Folder = strings.Join(folders, "/")
+	}
+	return loc
+}
+
+// entityFiles returns the entity files of a type, which is empty if the project has no such entities.
+func entityFiles(project *jbproj.Project, typeName string, sep string) ([]string, error) {
+	paths, err := project.EntityFiles(typeName, sep)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return paths, err
+}
+
+// parseEntities reads the entities of a type, which is empty if the project has no such entities.
+func parseEntities(project *jbproj.Project, typeName string, sep string) ([]*entity.Entity, error) {
+	entities, err := project.ParseEntities(typeName, sep)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return entities, err
+}
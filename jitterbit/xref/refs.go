@@ -0,0 +1,45 @@
+package xref
+
+import (
+	"strings"
+
+	"jbextractor/jitterbit/javascript"
+	"jbextractor/jitterbit/script"
+)
+
+// Tags enclosing the code of JavaScript scripts.
+const (
+	JS_OPEN_TAG  = "<javascript>"
+	JS_CLOSE_TAG = "</javascript>"
+)
+
+// JavaScriptCode returns the code of a <javascript> script body.
+func JavaScriptCode(src string) (string, bool) {
+	trimmed := strings.TrimSpace(src)
+	if !strings.HasPrefix(trimmed, JS_OPEN_TAG) || !strings.HasSuffix(trimmed, JS_CLOSE_TAG) {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(trimmed, JS_OPEN_TAG), JS_CLOSE_TAG), true
+}
+
+// ScriptReferences returns the entity IDs statically referenced by a Jitterbit Script or <javascript> script body in source order.
+func ScriptReferences(src string) []string {
+	ids := []string{}
+	if code, ok := JavaScriptCode(src); ok {
+		prog, _ := javascript.Parse(code)
+		for _, ref := range javascript.FindReferences(prog) {
+			if ref.Id != "" {
+				ids = append(ids, ref.Id)
+			}
+		}
+		return ids
+	}
+
+	parsed, _ := script.Parse(src)
+	for _, ref := range script.FindReferences(parsed) {
+		if ref.Id != "" {
+			ids = append(ids, ref.Id)
+		}
+	}
+	return ids
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"embed"
 	"log"
+	"os"
 	"runtime"
 
 	"github.com/wailsapp/wails/v2"
@@ -18,6 +19,11 @@ func main() {
 	// Create an instance of the app structure
 	app := NewApp(runtime.GOOS)
 
+	// Run a headless command instead of the GUI
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(app, os.Args[1:]))
+	}
+
 	// Create application with options
 	err := wails.Run(&options.App{
 		Title:             "Jitterbit Extractor",
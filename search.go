@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"jbextractor/jitterbit/entity"
+	jbproj "jbextractor/jitterbit/project"
+	"jbextractor/jitterbit/xref"
+)
+
+// Entity content displayed in the preview pane.
+type EntityPreview struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Slash-separated parent folder names.
+	Folder  string `json:"folder"`
+	Content string `json:"content"`
+}
+
+// A single script line containing the search query.
+type LineMatch struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// A script containing the search query.
+type SearchResult struct {
+	Id      string      `json:"id"`
+	Name    string      `json:"name"`
+	Folder  string      `json:"folder"`
+	Matches []LineMatch `json:"matches"`
+}
+
+// GetEntityContent returns the content of a single entity. Script references are resolved to <TAG> paths.
+func (a *App) GetEntityContent(projectPath string, env string, id string) *EntityPreview {
+	if projectPath == "" || env == "" || id == "" {
+		a.logWarning("[GetEntityContent] Empty project path, environment or entity ID")
+		return nil
+	}
+
+	envPath := fmt.Sprintf("%s%s%s", projectPath, a.pathSep, env)
+	project, err := jbproj.ParseProject(envPath, a.pathSep)
+	if err != nil {
+		a.logError(err)
+		return nil
+	}
+
+	et, ent, folders := project.FindEntity(id)
+	if ent == nil {
+		a.logWarning(fmt.Sprintf("[GetEntityContent] Entity %s could not be found", id))
+		return nil
+	}
+
+	preview := EntityPreview{
+		Id:     ent.Id,
+		Name:   ent.Name,
+		Type:   et.Type,
+		Folder: strings.Join(folders, "/"),
+	}
+
+	entityPath := fmt.Sprintf("%s%sData%s%s%s%s.xml", envPath, a.pathSep, a.pathSep, et.Type, a.pathSep, id)
+	if et.Type != jbproj.SCRIPT {
+		data, err := os.ReadFile(entityPath)
+		if err != nil {
+			a.logError(err)
+			return nil
+		}
+		preview.Content = string(data)
+		return &preview
+	}
+
+	script, err := entity.ParseEntity(entityPath)
+	if err != nil {
+		a.logError(err)
+		return nil
+	}
+
+	if code, ok := xref.JavaScriptCode(script.KongaString); ok {
+		preview.Content = fmt.Sprintf("%s%s%s", xref.JS_OPEN_TAG, a.resolveJavaScriptReferences(project, ent.Name, code), xref.JS_CLOSE_TAG)
+	} else {
+		preview.Content = a.resolveReferences(project, ent.Name, script.KongaString)
+	}
+	return &preview
+}
+
+// SearchScripts performs a case-insensitive full-text search across all script bodies of an environment.
+func (a *App) SearchScripts(projectPath string, env string, query string) []SearchResult {
+	if projectPath == "" || env == "" || query == "" {
+		a.logWarning("[SearchScripts] Empty project path, environment or query")
+		return nil
+	}
+
+	envPath := fmt.Sprintf("%s%s%s", projectPath, a.pathSep, env)
+	project, err := jbproj.ParseProject(envPath, a.pathSep)
+	if err != nil {
+		a.logError(err)
+		return nil
+	}
+
+	scriptType := project.GetEntityType(jbproj.SCRIPT)
+	scripts, err := project.ParseEntities(jbproj.SCRIPT, a.pathSep)
+	if err != nil {
+		a.logError(err)
+		return nil
+	}
+
+	needle := strings.ToLower(query)
+	results := []SearchResult{}
+	for _, script := range scripts {
+		matches := []LineMatch{}
+		for idx, line := range strings.Split(script.KongaString, "\n") {
+			if strings.Contains(strings.ToLower(line), needle) {
+				matches = append(matches, LineMatch{Line: idx + 1, Text: strings.TrimRight(line, "\r")})
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		ent, folders := scriptType.FindEntityFolders(script.Header.Id)
+		if ent == nil {
+			a.logWarning(fmt.Sprintf("[SearchScripts] Script %s was not found in project.xml", script.Header.Id))
+			continue
+		}
+
+		results = append(results, SearchResult{
+			Id:      ent.Id,
+			Name:    ent.Name,
+			Folder:  strings.Join(folders, "/"),
+			Matches: matches,
+		})
+	}
+
+	return results
+}